@@ -78,7 +78,7 @@ func (o *obsPercent) Update(state, prevState int) {
 }
 
 func main() {
-	subject := observer.NewSubject[int]()
+	subject := observer.NewSubject[int](observer.WithDistinct[int]())
 	subject.SetState(4)
 	observer1 := &obsBehaviour{
 		name:      "Behaviour observer",
@@ -89,7 +89,12 @@ func main() {
 		change: 0,
 	}
 
-	subject.Attach(observer1, observer2)
+	observer3 := observer.EventFunc[int](func(e observer.Event[int]) {
+		log.Println("Event observer", "version", e.Version, "at", e.Time.Format("15:04:05.000"))
+	},
+	)
+
+	subject.Attach(observer1, observer2, observer3)
 	subject.SetState(-2)
 	subject.SetState(-2)
 	subject.SetState(4)
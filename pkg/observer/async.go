@@ -0,0 +1,260 @@
+package observer
+
+import (
+	"context"
+	"sync"
+)
+
+// OverflowPolicy defines what asynchronous Subject does
+// when queue of a slow Observer is full.
+type OverflowPolicy int
+
+const (
+	// Block makes SetState wait until Observer queue has free space.
+	Block OverflowPolicy = iota
+	// DropOldest removes the oldest pending notification to make room for the new one.
+	DropOldest
+	// DropNewest discards the new notification.
+	DropNewest
+	// Coalesce merges the new state into the newest pending notification,
+	// Observer skips intermediate states, but keeps receiving consistent prevState.
+	Coalesce
+)
+
+// WithAsync makes Subject deliver notifications asynchronously.
+// Every attached Observer gets its own queue of given size and a worker goroutine,
+// so slow Observer instances do not stall SetState or each other.
+// policy defines what happens when the queue is full.
+// Changes replayed on attach are queued on top of given size,
+// policy never drops or coalesces them.
+// Asynchronous Subject should be closed with Close or Shutdown to stop workers.
+func WithAsync[T any](size int, policy OverflowPolicy) Option[T] {
+	if size < 1 {
+		panic("queue size must be positive")
+	}
+
+	return func(s *Subject[T]) {
+		s.queueSize = size
+		s.policy = policy
+	}
+}
+
+// delivery is a single pending notification.
+type delivery[T any] struct {
+	ctx   context.Context
+	event Event[T]
+}
+
+// queue is a bounded FIFO of pending notifications of a single Observer.
+type queue[T any] struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	items   []delivery[T]
+	replays int // replayed notifications at the head of items, they do not count against size
+	size    int
+	policy  OverflowPolicy
+	closed  bool // no new notifications are accepted, pending ones are still delivered
+}
+
+// newQueue creates queue with given size and overflow policy.
+func newQueue[T any](size int, policy OverflowPolicy) *queue[T] {
+	q := &queue[T]{
+		items:  make([]delivery[T], 0, size),
+		size:   size,
+		policy: policy,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// replay puts replayed events at the head of the queue.
+// Overflow policy never drops or coalesces them.
+func (q *queue[T]) replay(events []Event[T]) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, e := range events {
+		q.items = append(q.items, delivery[T]{ctx: context.Background(), event: e})
+	}
+	q.replays += len(events)
+}
+
+// push adds d to the queue according to queue overflow policy
+// and returns number of notifications dropped to do so,
+// coalesced notification counts as dropped.
+// push is a no-op on closed queue.
+func (q *queue[T]) push(d delivery[T]) (dropped int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && len(q.items)-q.replays >= q.size {
+		switch q.policy {
+		case DropOldest:
+			q.items = append(q.items[:q.replays], q.items[q.replays+1:]...)
+			dropped++
+		case DropNewest:
+			return dropped + 1
+		case Coalesce:
+			last := &q.items[len(q.items)-1]
+			last.ctx = d.ctx
+			last.event.State, last.event.Version, last.event.Time = d.event.State, d.event.Version, d.event.Time
+			return dropped + 1
+		default:
+			q.cond.Wait()
+		}
+	}
+	if q.closed {
+		return dropped
+	}
+
+	q.items = append(q.items, d)
+	q.cond.Broadcast()
+	return dropped
+}
+
+// pop waits for the next notification.
+// pop reports false once queue is closed and drained, or canceled.
+func (q *queue[T]) pop() (delivery[T], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return delivery[T]{}, false
+	}
+
+	d := q.items[0]
+	q.items = append(q.items[:0], q.items[1:]...)
+	if q.replays > 0 {
+		q.replays--
+	}
+	q.cond.Broadcast()
+	return d, true
+}
+
+// close stops accepting notifications, pending ones are still delivered.
+func (q *queue[T]) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
+
+// cancel stops accepting notifications, drops pending ones and returns their number.
+func (q *queue[T]) cancel() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	dropped := len(q.items)
+	q.closed = true
+	q.items = q.items[:0]
+	q.replays = 0
+	q.cond.Broadcast()
+	return dropped
+}
+
+// run delivers queued notifications to sub until its queue is closed and drained.
+// Notifications whose context is done by then are skipped
+// and reported as *NotifyError.
+func (s *Subject[T]) run(sub *subscriber[T]) {
+	defer s.workers.Done()
+
+	for {
+		d, ok := sub.queue.pop()
+		if !ok {
+			return
+		}
+		if err := d.ctx.Err(); err != nil {
+			s.fail(&NotifyError[T]{Skipped: []any{sub.source()}, Err: err})
+			continue
+		}
+		if err := s.deliver(d.ctx, sub, d.event); err != nil {
+			s.report(err)
+		}
+	}
+}
+
+// Close detaches all Observer instances and waits until asynchronous
+// notifications that are already queued are delivered.
+// After Close, SetState only updates state and Attach is a no-op.
+// Subject must be initialized before Close calls.
+func (s *Subject[T]) Close() {
+	_ = s.Shutdown(context.Background())
+}
+
+// Shutdown works like Close, but stops waiting for queued notifications once ctx is done.
+// Notifications that are still pending at that moment are dropped
+// and ctx error is returned.
+// Subject must be initialized before Shutdown calls.
+func (s *Subject[T]) Shutdown(ctx context.Context) error {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	s.mu.Lock()
+	s.closed = true
+	onClose := s.onClose
+	s.onClose = nil
+	s.mu.Unlock()
+
+	for _, f := range onClose {
+		f()
+	}
+
+	var (
+		queued  []*subscriber[T]
+		closers []closer
+	)
+	for _, sub := range s.detach(func(*subscriber[T]) bool { return true }) {
+		if sub.queue != nil {
+			sub.queue.close()
+			queued = append(queued, sub)
+		}
+		if c, ok := sub.observer.(closer); ok {
+			closers = append(closers, c)
+		}
+	}
+	defer func() {
+		for _, c := range closers {
+			c.close()
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		for _, sub := range queued {
+			s.stop(sub)
+		}
+		return ctx.Err()
+	}
+}
+
+// closer is implemented by internal Observer instances
+// that must release resources once their Subject is closed.
+type closer interface {
+	close()
+}
+
+// addCloseHook registers f to run when Subject is closed.
+// f runs immediately if Subject is already closed.
+func (s *Subject[T]) addCloseHook(f func()) {
+	s.mu.Lock()
+	if !s.closed {
+		s.onClose = append(s.onClose, f)
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	f()
+}
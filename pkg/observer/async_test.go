@@ -0,0 +1,221 @@
+package observer
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateObserver is a helper Observer implementation that blocks inside
+// the first Update call until release is closed.
+type gateObserver struct {
+	transitionObserver
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func newGateObserver() *gateObserver {
+	return &gateObserver{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (o *gateObserver) Update(state, prevState int) {
+	o.once.Do(func() {
+		close(o.started)
+		<-o.release
+	},
+	)
+	o.transitionObserver.Update(state, prevState)
+}
+
+// fillBehindGate makes o busy with state 1 and then sets states 2..last.
+func fillBehindGate(t *testing.T, s *Subject[int], o *gateObserver, last int) {
+	t.Helper()
+
+	s.SetState(1)
+	select {
+	case <-o.started:
+	case <-time.After(time.Second):
+		t.Fatalf("observer did not receive first notification")
+	}
+	for i := 2; i <= last; i++ {
+		s.SetState(i)
+	}
+}
+
+func TestAsyncBlock(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithAsync[int](2, Block))
+	o := &transitionObserver{}
+	s.Attach(o)
+
+	for i := 1; i <= 100; i++ {
+		s.SetState(i)
+	}
+	s.Close()
+
+	if len(o.transitions) != 100 {
+		t.Fatalf("observer received %d updates, want 100", len(o.transitions))
+	}
+	for i, tr := range o.transitions {
+		if want := [2]int{i + 1, i}; tr != want {
+			t.Fatalf("transition %d = %v, want %v", i, tr, want)
+		}
+	}
+}
+
+func TestAsyncSlowObserverDoesNotStallOthers(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithAsync[int](1, Coalesce))
+	slow := newGateObserver()
+	fast := &testObserver{}
+	s.Attach(slow, fast)
+
+	fillBehindGate(t, s, slow, 10)
+
+	eventually(t, time.Second, func() bool {
+		got, ok := fast.lastState(t)
+		return ok && got == 10
+	},
+	)
+
+	close(slow.release)
+	s.Close()
+}
+
+func TestAsyncOverflowPolicies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+		want   [][2]int
+	}{
+		{
+			name:   "DropOldest",
+			policy: DropOldest,
+			want:   [][2]int{{1, 0}, {5, 4}, {6, 5}},
+		},
+		{
+			name:   "DropNewest",
+			policy: DropNewest,
+			want:   [][2]int{{1, 0}, {2, 1}, {3, 2}},
+		},
+		{
+			name:   "Coalesce",
+			policy: Coalesce,
+			want:   [][2]int{{1, 0}, {2, 1}, {6, 2}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewSubject[int](WithAsync[int](2, tt.policy))
+			o := newGateObserver()
+			s.Attach(o)
+
+			fillBehindGate(t, s, o, 6)
+			close(o.release)
+			s.Close()
+
+			if !reflect.DeepEqual(o.transitions, tt.want) {
+				t.Fatalf("observer received %v, want %v", o.transitions, tt.want)
+			}
+		},
+		)
+	}
+}
+
+func TestAsyncDetachDropsPending(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithAsync[int](4, Block))
+	o := newGateObserver()
+	sub := s.Attach(o)
+
+	fillBehindGate(t, s, o, 4)
+	sub.Unsubscribe()
+	close(o.release)
+	s.Close()
+
+	if want := [][2]int{{1, 0}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("detached observer received %v, want %v", o.transitions, want)
+	}
+}
+
+func TestShutdownCancelsPending(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithAsync[int](4, Block))
+	o := newGateObserver()
+	s.Attach(o)
+
+	fillBehindGate(t, s, o, 4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(o.release)
+
+	// once the worker exits nothing else can be delivered
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("worker did not exit after Shutdown")
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if want := [][2]int{{1, 0}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("observer received %v after Shutdown, want %v", o.transitions, want)
+	}
+}
+
+func TestCloseSync(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o := &testObserver{}
+	s.Attach(o)
+
+	s.Close()
+	s.Attach(o)
+	s.SetState(1)
+
+	if len(o.states) != 0 {
+		t.Fatalf("observer of closed subject received %v", o.states)
+	}
+	if got := s.State(); got != 1 {
+		t.Fatalf("State() of closed subject = %d, want 1", got)
+	}
+}
+
+// eventually waits (with a timeout) for condition f to become true.
+func eventually(t *testing.T, d time.Duration, f func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		if f() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("condition not satisfied within %v", d)
+}
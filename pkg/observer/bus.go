@@ -0,0 +1,262 @@
+package observer
+
+import (
+	"strings"
+	"sync"
+)
+
+// Handler receives events published to EventBus topics.
+type Handler func(topic string, event any)
+
+// EventBus routes events published to named topics, e.g. "user.created".
+// Every topic is a Subject created on first use.
+// Subscription patterns may contain wildcards: "*" matches a single
+// dot-separated segment of topic name, ">" at the end matches one or more segments,
+// so "user.*" matches "user.created" and "order.>" matches "order.paid.card".
+type EventBus struct {
+	mu       sync.RWMutex // protects topics, handlers and closed
+	topics   map[string]*Subject[any]
+	handlers []*busHandler
+	opts     []Option[any]
+	closed   bool
+}
+
+// busHandler is a Handler subscribed to topics matching its pattern.
+type busHandler struct {
+	pattern []string
+	handler Handler
+	subs    []Subscription
+}
+
+// NewEventBus creates new EventBus.
+// Options configure every topic Subject.
+func NewEventBus(opts ...Option[any]) *EventBus {
+	return &EventBus{
+		topics: make(map[string]*Subject[any]),
+		opts:   opts,
+	}
+}
+
+// Subject returns Subject of the topic with given name, creating it on first use.
+// EventBus must be initialized before Subject calls.
+func (b *EventBus) Subject(topic string) *Subject[any] {
+	if b == nil {
+		panic("event bus is not initialized")
+	}
+
+	return b.subject(topic, false)
+}
+
+// subject returns Subject of the topic, creating it on first use.
+// Handlers attached to Subject created for publish get no replay,
+// its state is about to be set, so they would see zero state first.
+func (b *EventBus) subject(topic string, publish bool) *Subject[any] {
+	b.mu.RLock()
+	s, ok := b.topics[topic]
+	b.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	b.mu.Lock()
+	if s, ok := b.topics[topic]; ok {
+		b.mu.Unlock()
+		return s
+	}
+	s = NewSubject(b.opts...)
+	if b.closed {
+		b.mu.Unlock()
+		s.Close()
+		return s
+	}
+	b.topics[topic] = s
+
+	var (
+		attached []*subscription[any]
+		opts     []AttachOption[any]
+	)
+	if publish {
+		opts = append(opts, withoutReplay[any]())
+	}
+	name := strings.Split(topic, ".")
+	for _, h := range b.handlers {
+		if matchTopic(h.pattern, name) {
+			sub := h.attach(topic, s, opts...)
+			h.subs = append(h.subs, sub)
+			attached = append(attached, sub)
+		}
+	}
+	b.mu.Unlock()
+
+	// replay calls handlers, which may use the bus
+	for _, sub := range attached {
+		sub.replay()
+	}
+	return s
+}
+
+// Publish notifies every Handler subscribed to topic about event.
+// EventBus must be initialized before Publish calls.
+func (b *EventBus) Publish(topic string, event any) {
+	if b == nil {
+		panic("event bus is not initialized")
+	}
+
+	b.subject(topic, true).SetState(event)
+}
+
+// Subscribe makes h receive events of every topic matching pattern,
+// including topics created later, and returns Subscription that stops it.
+// EventBus must be initialized before Subscribe calls.
+func (b *EventBus) Subscribe(pattern string, h Handler) Subscription {
+	if b == nil {
+		panic("event bus is not initialized")
+	}
+	if h == nil {
+		panic("handler is nil")
+	}
+
+	bh := &busHandler{
+		pattern: strings.Split(pattern, "."),
+		handler: h,
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return &busSubscription{bus: b}
+	}
+	var attached []*subscription[any]
+	for topic, s := range b.topics {
+		if matchTopic(bh.pattern, strings.Split(topic, ".")) {
+			sub := bh.attach(topic, s)
+			bh.subs = append(bh.subs, sub)
+			attached = append(attached, sub)
+		}
+	}
+	b.handlers = append(b.handlers, bh)
+	b.mu.Unlock()
+
+	// replay calls h, which may use the bus
+	for _, sub := range attached {
+		sub.replay()
+	}
+
+	return &busSubscription{
+		bus:     b,
+		handler: bh,
+	}
+}
+
+// Close closes every topic Subject, Handler instances receive no more events.
+// EventBus must be initialized before Close calls.
+func (b *EventBus) Close() {
+	if b == nil {
+		panic("event bus is not initialized")
+	}
+
+	b.mu.Lock()
+	b.closed = true
+	topics := b.topics
+	b.topics = make(map[string]*Subject[any])
+	b.handlers = nil
+	b.mu.Unlock()
+
+	for _, s := range topics {
+		s.Close()
+	}
+}
+
+// attach makes handler receive events of topic Subject s.
+// Caller must call replay on returned subscription without holding bus lock.
+func (h *busHandler) attach(topic string, s *Subject[any], opts ...AttachOption[any]) *subscription[any] {
+	sub, _ := s.attach([]Observer[any]{ObserverFunc[any](func(event, _ any) {
+		h.handler(topic, event)
+	},
+	)}, opts...)
+	return sub
+}
+
+// busSubscription implements Subscription for EventBus handlers.
+type busSubscription struct {
+	bus     *EventBus
+	handler *busHandler
+}
+
+// Unsubscribe stops handler from receiving events of every topic.
+func (s *busSubscription) Unsubscribe() {
+	if s.handler == nil {
+		return
+	}
+
+	b := s.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, h := range b.handlers {
+		if h == s.handler {
+			b.handlers = append(b.handlers[:i:i], b.handlers[i+1:]...)
+			break
+		}
+	}
+	for _, sub := range s.handler.subs {
+		sub.Unsubscribe()
+	}
+	s.handler.subs = nil
+}
+
+// matchTopic reports whether topic name matches pattern, both split into segments.
+func matchTopic(pattern, name []string) bool {
+	for i, p := range pattern {
+		if p == ">" && i == len(pattern)-1 {
+			return len(name) > i
+		}
+		if i >= len(name) || (p != "*" && p != name[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(name)
+}
+
+// Topic is a typed handle of a single EventBus topic.
+type Topic[T any] struct {
+	bus  *EventBus
+	name string
+}
+
+// NewTopic returns typed handle of EventBus topic with given name.
+func NewTopic[T any](b *EventBus, name string) Topic[T] {
+	return Topic[T]{
+		bus:  b,
+		name: name,
+	}
+}
+
+// Name returns topic name.
+func (t Topic[T]) Name() string {
+	return t.name
+}
+
+// Publish publishes event to the topic.
+func (t Topic[T]) Publish(event T) {
+	t.bus.Publish(t.name, event)
+}
+
+// Subscribe makes fn receive events of type T published to the topic.
+func (t Topic[T]) Subscribe(fn func(event T)) Subscription {
+	return SubscribeTopic(t.bus, t.name, func(_ string, event T) {
+		fn(event)
+	},
+	)
+}
+
+// SubscribeTopic makes fn receive events of type T published to topics
+// matching pattern, events of other types are skipped.
+func SubscribeTopic[T any](b *EventBus, pattern string, fn func(topic string, event T)) Subscription {
+	return b.Subscribe(pattern, func(topic string, event any) {
+		if e, ok := event.(T); ok {
+			fn(topic, e)
+		}
+	},
+	)
+}
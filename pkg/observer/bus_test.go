@@ -0,0 +1,281 @@
+package observer
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// busRecorder is a helper Handler that stores every "topic=event" it receives.
+type busRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *busRecorder) handle(topic string, event any) {
+	r.mu.Lock()
+	r.events = append(r.events, topic+"="+toString(event))
+	r.mu.Unlock()
+}
+
+func toString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return "?"
+}
+
+func TestEventBusExact(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBus()
+	r := &busRecorder{}
+	b.Subscribe("user.created", r.handle)
+
+	b.Publish("user.created", "ann")
+	b.Publish("user.deleted", "bob")
+	b.Publish("user.created", "ann")
+
+	if want := []string{"user.created=ann", "user.created=ann"}; !reflect.DeepEqual(r.events, want) {
+		t.Fatalf("handler received %v, want %v", r.events, want)
+	}
+}
+
+func TestEventBusWildcards(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{pattern: "user.*", want: []string{"user.created=1", "user.deleted=2"}},
+		{pattern: "*.paid", want: []string{"order.paid=4"}},
+		{pattern: "order.>", want: []string{"order.paid=4", "order.paid.card=5"}},
+		{pattern: ">", want: []string{"order.paid.card=5", "order.paid=4", "user=6", "user.created=1", "user.deleted=2", "user.profile.updated=3"}},
+		{pattern: "user", want: []string{"user=6"}},
+		{pattern: "user.*.updated", want: []string{"user.profile.updated=3"}},
+	}
+
+	b := NewEventBus()
+	recorders := make([]*busRecorder, len(tests))
+	for i, tt := range tests {
+		recorders[i] = &busRecorder{}
+		b.Subscribe(tt.pattern, recorders[i].handle)
+	}
+
+	b.Publish("user.created", "1")
+	b.Publish("user.deleted", "2")
+	b.Publish("user.profile.updated", "3")
+	b.Publish("order.paid", "4")
+	b.Publish("order.paid.card", "5")
+	b.Publish("user", "6")
+
+	for i, tt := range tests {
+		got := recorders[i].events
+		sort.Strings(got)
+		sort.Strings(tt.want)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pattern %q received %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestEventBusSubscribeExistingTopic(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBus()
+	b.Publish("order.paid", "1")
+
+	r := &busRecorder{}
+	b.Subscribe("order.*", r.handle)
+	b.Publish("order.paid", "2")
+
+	if want := []string{"order.paid=2"}; !reflect.DeepEqual(r.events, want) {
+		t.Fatalf("handler received %v, want %v", r.events, want)
+	}
+}
+
+func TestEventBusLazySubjects(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBus()
+	b.Subscribe("user.>", func(string, any) {})
+
+	if len(b.topics) != 0 {
+		t.Fatalf("subscribing created %d topics, want none", len(b.topics))
+	}
+
+	s := b.Subject("user.created")
+	if b.Subject("user.created") != s {
+		t.Fatalf("Subject() returned different subjects for the same topic")
+	}
+	if n := len(s.observers); n != 1 {
+		t.Fatalf("lazily created topic has %d observers, want 1 matching handler", n)
+	}
+}
+
+func TestEventBusUnsubscribe(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBus()
+	r := &busRecorder{}
+	sub := b.Subscribe("user.*", r.handle)
+
+	b.Publish("user.created", "1")
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	b.Publish("user.created", "2")
+	b.Publish("user.deleted", "3")
+
+	if want := []string{"user.created=1"}; !reflect.DeepEqual(r.events, want) {
+		t.Fatalf("handler received %v, want %v", r.events, want)
+	}
+}
+
+func TestEventBusOptions(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBus(WithReplay[any](1))
+	b.Publish("config.changed", "v1")
+
+	r := &busRecorder{}
+	b.Subscribe("config.*", r.handle)
+
+	if want := []string{"config.changed=v1"}; !reflect.DeepEqual(r.events, want) {
+		t.Fatalf("handler received %v, want replayed %v", r.events, want)
+	}
+}
+
+func TestEventBusReplayHandlerUsesBus(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBus(WithBehavior[any]())
+	b.Publish("a.x", "1")
+	audit := &busRecorder{}
+	b.Subscribe("audit.>", audit.handle)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// replay of existing topic on Subscribe
+		b.Subscribe("a.*", func(topic string, event any) {
+			b.Publish("audit.seen", topic)
+		},
+		)
+		// new topic created by Publish, handler gets the event only once
+		b.Publish("a.y", "2")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("handler using the bus during replay deadlocked")
+	}
+
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	if want := []string{"audit.seen=a.x", "audit.seen=a.y"}; !reflect.DeepEqual(audit.events, want) {
+		t.Fatalf("audit handler received %v, want %v", audit.events, want)
+	}
+}
+
+type userCreated struct {
+	name string
+}
+
+type orderPaid struct {
+	amount int
+}
+
+func TestTopic(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBus()
+	users := NewTopic[userCreated](b, "user.created")
+
+	var got []string
+	users.Subscribe(func(e userCreated) { got = append(got, e.name) })
+	users.Publish(userCreated{name: "ann"})
+	b.Publish("user.created", "not a userCreated")
+
+	if want := []string{"ann"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("typed subscriber received %v, want %v", got, want)
+	}
+	if users.Name() != "user.created" {
+		t.Fatalf("Name() = %q, want %q", users.Name(), "user.created")
+	}
+}
+
+func TestSubscribeTopic(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBus()
+	var got []string
+	SubscribeTopic(b, "*.paid", func(topic string, e orderPaid) {
+		got = append(got, topic+":"+strings.Repeat("$", e.amount))
+	},
+	)
+
+	NewTopic[orderPaid](b, "order.paid").Publish(orderPaid{amount: 2})
+	NewTopic[orderPaid](b, "invoice.paid").Publish(orderPaid{amount: 1})
+	NewTopic[userCreated](b, "user.paid").Publish(userCreated{name: "ann"})
+
+	if want := []string{"order.paid:$$", "invoice.paid:$"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("typed pattern subscriber received %v, want %v", got, want)
+	}
+}
+
+func TestEventBusClose(t *testing.T) {
+	t.Parallel()
+
+	b := NewEventBus()
+	r := &busRecorder{}
+	b.Subscribe(">", r.handle)
+	b.Publish("a", "1")
+
+	b.Close()
+	b.Publish("a", "2")
+	b.Subscribe(">", r.handle)
+	b.Publish("b", "3")
+
+	if want := []string{"a=1"}; !reflect.DeepEqual(r.events, want) {
+		t.Fatalf("handler received %v, want %v", r.events, want)
+	}
+}
+
+// negative tests
+
+func TestNilEventBusMethodsShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	var b *EventBus
+
+	check := func(name string, f func()) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("%s did not panic on nil receiver", name)
+			}
+		}()
+		f()
+	}
+
+	check("Subject", func() { b.Subject("a") })
+	check("Publish", func() { b.Publish("a", 1) })
+	check("Subscribe", func() { b.Subscribe("a", func(string, any) {}) })
+	check("Close", func() { b.Close() })
+}
+
+func TestEventBusNilHandlerShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("Subscribe did not panic on nil handler")
+		}
+	}()
+	NewEventBus().Subscribe("a", nil)
+}
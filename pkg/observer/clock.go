@@ -0,0 +1,52 @@
+package observer
+
+import (
+	"time"
+)
+
+// Clock tells time to Subject and time-based Observer wrappers,
+// tests may replace it with a fake one (see observertest.Clock).
+type Clock interface {
+	// Now returns current time.
+	Now() time.Time
+	// AfterFunc calls f once d elapses, f may run on any goroutine.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending call scheduled by Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the call, it reports false if the call already happened or was stopped.
+	Stop() bool
+}
+
+// SystemClock is a Clock that uses time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// now returns current time told by clock of Subject.
+func (s *Subject[T]) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+// WithClock makes Subject stamp Event instances with time told by c.
+func WithClock[T any](c Clock) Option[T] {
+	if c == nil {
+		panic("clock is nil")
+	}
+
+	return func(s *Subject[T]) {
+		s.clock = c
+	}
+}
@@ -0,0 +1,62 @@
+package observer
+
+import (
+	"testing"
+	"time"
+)
+
+// fixedClock is a helper Clock that always tells the same time.
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func (c fixedClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+func TestWithClock(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := NewSubject[int](WithClock[int](fixedClock{now: now}))
+	r := &eventRecorder{}
+	s.Attach(r)
+	s.SetState(1)
+
+	if got := r.events[0].Time; !got.Equal(now) {
+		t.Fatalf("event time %v, want %v", got, now)
+	}
+}
+
+func TestSystemClockAfterFunc(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+	SystemClock.AfterFunc(time.Millisecond, func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("AfterFunc did not call f within 1s")
+	}
+
+	if SystemClock.AfterFunc(time.Hour, func() {}).Stop() != true {
+		t.Fatalf("Stop() of pending call = false, want true")
+	}
+}
+
+// negative tests
+
+func TestWithClockNilShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("WithClock(nil) did not panic")
+		}
+	}()
+	WithClock[int](nil)
+}
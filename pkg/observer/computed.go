@@ -0,0 +1,378 @@
+package observer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrCycle is returned when Computed depends on itself, directly or through other Computed values.
+var ErrCycle = errors.New("computed dependency cycle")
+
+// Graph coordinates Computed values that depend on each other.
+// Once a Subject changes, every Computed that depends on it is recomputed
+// at most once, after every Computed it depends on, so it never sees
+// a mix of old and new states (no glitches).
+//
+// Changes are propagated synchronously by the goroutine that changed the Subject,
+// one change at a time. Computation functions and Observer instances of Computed
+// subjects must not change state of Subject instances read by the Graph
+// or close its Computed values, that would deadlock.
+type Graph struct {
+	mu      sync.Mutex      // serializes propagation and protects nodes and sources
+	nodes   map[any]*node   // keyed by Subject of Computed
+	sources map[any]*source // regular Subject instances read by nodes
+	onError ErrorHandler
+}
+
+// source is a regular Subject watched by Graph.
+// Graph attaches to every Subject once, so all nodes that read it
+// are recomputed by a single propagation.
+type source struct {
+	sub   Subscription
+	nodes map[*node]struct{}
+}
+
+// NewGraph creates empty Graph.
+// Failures of recomputation (e.g. ErrCycle) are reported to h, if it is not nil.
+func NewGraph(h ErrorHandler) *Graph {
+	return &Graph{
+		nodes:   make(map[any]*node),
+		sources: make(map[any]*source),
+		onError: h,
+	}
+}
+
+// node is a single Computed value in Graph.
+type node struct {
+	graph      *Graph
+	height     int                 // 1 + max height of Computed dependencies
+	deps       map[any]*dependency // keyed by Subject
+	dependents map[*node]struct{}  // Computed values that read this one
+	compute    func(d *Deps) error // recomputes value and returns its failure
+	changed    func() bool         // reports whether value changed since last call
+	err        error               // failure of the last computation
+	closed     bool
+}
+
+// dependency is a Subject that node read during its last computation.
+type dependency struct {
+	node    *node                         // set if Subject belongs to Computed
+	attach  func() (Subscription, uint64) // set for regular Subject, attaches Graph to it and returns current version
+	version uint64                        // version of regular Subject state that was read
+}
+
+// Deps tracks Subject instances read by computation of a Computed value.
+// Deps is valid only during the computation.
+type Deps struct {
+	node *node
+	read map[any]*dependency
+	err  error
+}
+
+// Read returns state of s and records s as dependency of the computation d belongs to.
+// Computation is run again once any of its dependencies changes.
+func Read[T any](d *Deps, s *Subject[T]) T {
+	if d == nil {
+		panic("deps are not initialized")
+	}
+
+	if _, ok := d.read[s]; ok {
+		return s.State()
+	}
+
+	if other, ok := d.node.graph.nodes[s]; ok {
+		if other.reaches(d.node) {
+			d.err = ErrCycle
+		} else {
+			d.read[s] = &dependency{node: other}
+		}
+		return s.State()
+	}
+
+	g := d.node.graph
+	state, version := s.snapshot()
+	d.read[s] = &dependency{
+		version: version,
+		attach: func() (Subscription, uint64) {
+			sub := s.AttachWith(EventFunc[T](func(e Event[T]) {
+				// current state of a new dependency was read already
+				if !e.Replay {
+					g.changed(s)
+				}
+			},
+			),
+			)
+			_, version := s.snapshot()
+			return sub, version
+		},
+	}
+	return state
+}
+
+// snapshot returns current state of Subject together with its version.
+func (s *Subject[T]) snapshot() (T, uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state, s.version
+}
+
+// reaches reports whether n is target or depends on it, directly or not.
+func (n *node) reaches(target *node) bool {
+	if n == target {
+		return true
+	}
+	for _, dep := range n.deps {
+		if dep.node != nil && dep.node.reaches(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// update recomputes n and replaces its dependencies with ones read by computation.
+// Dependencies stay untouched if computation fails.
+// update reports stale if a Subject that Graph did not watch yet changed
+// after computation read it, its change was not seen by Graph, so n must be recomputed.
+// Caller must hold graph mu.
+func (n *node) update() (stale bool, err error) {
+	d := &Deps{
+		node: n,
+		read: make(map[any]*dependency),
+	}
+
+	if err := n.compute(d); err != nil {
+		n.err = err
+		return false, err
+	}
+	n.err = nil
+
+	for key, dep := range n.deps {
+		if _, ok := d.read[key]; !ok {
+			n.forget(key, dep)
+		}
+	}
+	for key, dep := range d.read {
+		if dep.node != nil {
+			dep.node.dependents[n] = struct{}{}
+			continue
+		}
+		src, ok := n.graph.sources[key]
+		if !ok {
+			sub, version := dep.attach()
+			stale = stale || version != dep.version
+			src = &source{sub: sub, nodes: make(map[*node]struct{})}
+			n.graph.sources[key] = src
+		}
+		src.nodes[n] = struct{}{}
+	}
+	n.deps = d.read
+	n.raise()
+	return stale, nil
+}
+
+// forget removes dependency of n on Subject with given key.
+// Graph is detached from regular Subject that no node reads anymore.
+// Caller must hold graph mu.
+func (n *node) forget(key any, dep *dependency) {
+	if dep.node != nil {
+		delete(dep.node.dependents, n)
+		return
+	}
+
+	src, ok := n.graph.sources[key]
+	if !ok {
+		return
+	}
+	delete(src.nodes, n)
+	if len(src.nodes) == 0 {
+		src.sub.Unsubscribe()
+		delete(n.graph.sources, key)
+	}
+}
+
+// raise recalculates height of n and of its dependents.
+func (n *node) raise() {
+	height := 1
+	for _, dep := range n.deps {
+		if dep.node != nil && dep.node.height >= height {
+			height = dep.node.height + 1
+		}
+	}
+	if height == n.height {
+		return
+	}
+
+	n.height = height
+	for dependent := range n.dependents {
+		dependent.raise()
+	}
+}
+
+// changed propagates change of a regular Subject with given key.
+func (g *Graph) changed(key any) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	src, ok := g.sources[key]
+	if !ok {
+		return
+	}
+	g.propagate(src.nodes)
+}
+
+// propagate recomputes dirty nodes and their dependents from the lowest
+// to the highest one, so every node is recomputed after all of its dependencies.
+// Caller must hold mu.
+func (g *Graph) propagate(start map[*node]struct{}) {
+	dirty := make(map[*node]struct{}, len(start))
+	for n := range start {
+		dirty[n] = struct{}{}
+	}
+	for len(dirty) > 0 {
+		var next *node
+		for n := range dirty {
+			if next == nil || n.height < next.height {
+				next = n
+			}
+		}
+		delete(dirty, next)
+
+		stale, err := next.update()
+		if err != nil {
+			if g.onError != nil {
+				g.onError(err)
+			}
+			continue
+		}
+		if next.changed() {
+			for dependent := range next.dependents {
+				dirty[dependent] = struct{}{}
+			}
+		}
+		if stale {
+			dirty[next] = struct{}{}
+		}
+	}
+}
+
+// compute runs fn and turns its panic or read of a dependency cycle into error.
+func compute[T any](fn func(d *Deps) T, d *Deps) (value T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("computation panicked: %v", r)
+		}
+	}()
+
+	value = fn(d)
+	return value, d.err
+}
+
+// Computed is a value computed from states of other Subject and Computed instances.
+// Its Subject changes whenever computation, run again after any of its dependencies
+// changes, returns a new value. Subject must not be changed directly.
+type Computed[T any] struct {
+	subject *Subject[T]
+	node    *node
+}
+
+// NewComputed creates Computed value in g, computed by fn.
+// fn reads its dependencies with Read, they may differ from one run to another.
+// Options, if any, configure Subject of Computed (e.g. WithDistinct
+// stops propagation when computed value does not change).
+// NewComputed returns ErrCycle if fn reads Computed that depends on the new one.
+func NewComputed[T any](g *Graph, fn func(d *Deps) T, opts ...Option[T]) (*Computed[T], error) {
+	if g == nil {
+		panic("graph is not initialized")
+	}
+	if fn == nil {
+		panic("computation is nil")
+	}
+
+	c := &Computed[T]{subject: NewSubject[T](opts...)}
+
+	var version uint64
+	c.node = &node{
+		graph:      g,
+		deps:       make(map[any]*dependency),
+		dependents: make(map[*node]struct{}),
+		compute: func(d *Deps) error {
+			value, err := compute(fn, d)
+			if err != nil {
+				return err
+			}
+			c.subject.SetState(value)
+			return nil
+		},
+		changed: func() bool {
+			c.subject.mu.RLock()
+			defer c.subject.mu.RUnlock()
+
+			changed := c.subject.version != version
+			version = c.subject.version
+			return changed
+		},
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stale, err := c.node.update()
+	if err != nil {
+		return nil, err
+	}
+	c.node.changed()
+	g.nodes[c.subject] = c.node
+	if stale {
+		g.propagate(map[*node]struct{}{c.node: {}})
+	}
+	return c, nil
+}
+
+// Subject returns Subject holding the computed value, Observer instances
+// may be attached to it and it may be read by other Computed values.
+func (c *Computed[T]) Subject() *Subject[T] {
+	return c.subject
+}
+
+// State returns the computed value.
+func (c *Computed[T]) State() T {
+	return c.subject.State()
+}
+
+// Err returns failure of the last computation, or nil if it succeeded.
+// Computed keeps its previous value and dependencies when computation fails.
+func (c *Computed[T]) Err() error {
+	g := c.node.graph
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return c.node.err
+}
+
+// Close removes Computed from its Graph, detaches it from dependencies
+// and closes its Subject. Computed values that read it keep its last value.
+func (c *Computed[T]) Close() {
+	g := c.node.graph
+	g.mu.Lock()
+	if c.node.closed {
+		g.mu.Unlock()
+		return
+	}
+
+	for key, dep := range c.node.deps {
+		c.node.forget(key, dep)
+	}
+	for dependent := range c.node.dependents {
+		delete(dependent.deps, c.subject)
+		dependent.raise()
+	}
+	delete(g.nodes, c.subject)
+	c.node.deps = nil
+	c.node.dependents = nil
+	c.node.closed = true
+	g.mu.Unlock()
+
+	c.subject.Close()
+}
@@ -0,0 +1,287 @@
+package observer
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// mustComputed is a helper that creates Computed or fails the test.
+func mustComputed[T any](t *testing.T, g *Graph, fn func(d *Deps) T, opts ...Option[T]) *Computed[T] {
+	t.Helper()
+
+	c, err := NewComputed(g, fn, opts...)
+	if err != nil {
+		t.Fatalf("NewComputed() = %v", err)
+	}
+	return c
+}
+
+func TestComputed(t *testing.T) {
+	t.Parallel()
+
+	a := NewSubject[int]()
+	a.SetState(1)
+	g := NewGraph(nil)
+	double := mustComputed(t, g, func(d *Deps) int { return Read(d, a) * 2 })
+	o := &transitionObserver{}
+	double.Subject().Attach(o)
+
+	if got := double.State(); got != 2 {
+		t.Fatalf("State() = %d, want 2", got)
+	}
+	a.SetState(5)
+	if want := [][2]int{{10, 2}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("observer received %v, want %v", o.transitions, want)
+	}
+}
+
+func TestComputedGlitchFree(t *testing.T) {
+	t.Parallel()
+
+	a := NewSubject[int]()
+	g := NewGraph(nil)
+	b := mustComputed(t, g, func(d *Deps) int { return Read(d, a) * 2 })
+	c := mustComputed(t, g, func(d *Deps) int { return Read(d, a) + 1 })
+	// e depends on a both directly and through d, so d is higher than b and c
+	d := mustComputed(t, g, func(deps *Deps) int { return Read(deps, b.Subject()) + Read(deps, c.Subject()) })
+
+	runs := 0
+	e := mustComputed(t, g, func(deps *Deps) [2]int {
+		runs++
+		return [2]int{Read(deps, a), Read(deps, d.Subject())}
+	},
+	)
+
+	var mu sync.Mutex
+	var seen [][2]int
+	e.Subject().Attach(ObserverFunc[[2]int](func(state, _ [2]int) {
+		mu.Lock()
+		seen = append(seen, state)
+		mu.Unlock()
+	},
+	))
+
+	for i := 1; i <= 3; i++ {
+		a.SetState(i)
+	}
+
+	if runs != 4 {
+		t.Fatalf("e was computed %d times, want 4", runs)
+	}
+	for _, state := range seen {
+		if state[1] != 3*state[0]+1 {
+			t.Fatalf("e observed inconsistent state %v", state)
+		}
+	}
+	if len(seen) != 3 {
+		t.Fatalf("e notified %d times, want 3", len(seen))
+	}
+}
+
+func TestComputedDynamicDependencies(t *testing.T) {
+	t.Parallel()
+
+	useX := NewSubject[bool]()
+	useX.SetState(true)
+	x, y := NewSubject[int](), NewSubject[int]()
+
+	runs := 0
+	g := NewGraph(nil)
+	c := mustComputed(t, g, func(d *Deps) int {
+		runs++
+		if Read(d, useX) {
+			return Read(d, x)
+		}
+		return Read(d, y)
+	},
+	)
+
+	y.SetState(1)
+	if runs != 1 {
+		t.Fatalf("change of unread dependency recomputed value")
+	}
+	useX.SetState(false)
+	x.SetState(2)
+	y.SetState(3)
+
+	if got := c.State(); got != 3 || runs != 3 {
+		t.Fatalf("State() = %d after %d runs, want 3 after 3 runs", got, runs)
+	}
+}
+
+func TestComputedWithDistinct(t *testing.T) {
+	t.Parallel()
+
+	a := NewSubject[int]()
+	g := NewGraph(nil)
+	parity := mustComputed(t, g, func(d *Deps) int { return Read(d, a) % 2 }, WithDistinct[int]())
+	runs := 0
+	mustComputed(t, g, func(d *Deps) int {
+		runs++
+		return Read(d, parity.Subject())
+	},
+	)
+
+	a.SetState(2)
+	a.SetState(4)
+	a.SetState(5)
+	if runs != 2 {
+		t.Fatalf("dependent of unchanged value was computed %d times, want 2", runs)
+	}
+}
+
+func TestComputedClose(t *testing.T) {
+	t.Parallel()
+
+	a := NewSubject[int]()
+	g := NewGraph(nil)
+	c := mustComputed(t, g, func(d *Deps) int { return Read(d, a) + 1 })
+	c.Close()
+	c.Close()
+
+	a.SetState(1)
+	if got := c.State(); got != 1 {
+		t.Fatalf("State() of closed value = %d, want 1", got)
+	}
+	if len(attached(a)) != 0 {
+		t.Fatalf("closed value is still attached to its dependency")
+	}
+}
+
+func TestComputedConcurrent(t *testing.T) {
+	t.Parallel()
+
+	a, b := NewSubject[int](), NewSubject[int]()
+	g := NewGraph(nil)
+	sum := mustComputed(t, g, func(d *Deps) int { return Read(d, a) + Read(d, b) })
+
+	var wg sync.WaitGroup
+	for _, s := range []*Subject[int]{a, b} {
+		s := s
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= 100; i++ {
+				s.SetState(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := sum.State(); got != 200 {
+		t.Fatalf("State() = %d, want 200", got)
+	}
+}
+
+func TestComputedSourceChangedDuringComputation(t *testing.T) {
+	t.Parallel()
+
+	// changeAfterRead makes another goroutine change s once computation read it
+	// and waits for the change, before Graph attaches to s
+	changeAfterRead := func(s *Subject[int], state int) {
+		done := make(chan struct{})
+		go func() {
+			s.SetState(state)
+			close(done)
+		}()
+		<-done
+	}
+
+	src := NewSubject[int]()
+	first := true
+	c := mustComputed(t, NewGraph(nil), func(d *Deps) int {
+		v := Read(d, src)
+		if first {
+			first = false
+			changeAfterRead(src, 42)
+		}
+		return v
+	},
+	)
+	if got := c.State(); got != 42 {
+		t.Fatalf("State() = %d after source changed during computation, want 42", got)
+	}
+
+	useY := NewSubject[bool]()
+	y := NewSubject[int]()
+	switched := false
+	dynamic := mustComputed(t, NewGraph(nil), func(d *Deps) int {
+		if !Read(d, useY) {
+			return -1
+		}
+		v := Read(d, y)
+		if !switched {
+			switched = true
+			changeAfterRead(y, 7)
+		}
+		return v
+	},
+	)
+	useY.SetState(true)
+	if got := dynamic.State(); got != 7 {
+		t.Fatalf("State() = %d after new dependency changed during computation, want 7", got)
+	}
+}
+
+// negative tests
+
+func TestComputedCycle(t *testing.T) {
+	t.Parallel()
+
+	r := &errorRecorder{}
+	g := NewGraph(r.handle)
+	closeCycle := NewSubject[bool]()
+	var second *Computed[int]
+	first := mustComputed(t, g, func(d *Deps) int {
+		if Read(d, closeCycle) {
+			return Read(d, second.Subject()) + 1
+		}
+		return 1
+	},
+	)
+	second = mustComputed(t, g, func(d *Deps) int { return Read(d, first.Subject()) + 1 })
+
+	closeCycle.SetState(true)
+
+	if !errors.Is(first.Err(), ErrCycle) {
+		t.Fatalf("Err() = %v, want %v", first.Err(), ErrCycle)
+	}
+	if r.len() != 1 || !errors.Is(r.errs[0], ErrCycle) {
+		t.Fatalf("reported errors %v, want %v", r.errs, ErrCycle)
+	}
+	if first.State() != 1 || second.State() != 2 {
+		t.Fatalf("values changed to %d and %d by cycle, want 1 and 2", first.State(), second.State())
+	}
+
+	closeCycle.SetState(false)
+	if first.Err() != nil {
+		t.Fatalf("Err() after cycle is broken = %v, want nil", first.Err())
+	}
+}
+
+func TestComputedPanic(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewComputed(NewGraph(nil), func(*Deps) int { panic("boom") }); err == nil {
+		t.Fatalf("NewComputed() of panicking computation returned no error")
+	}
+}
+
+func TestComputedInvalidArgumentsShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	check := func(name string, f func()) {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("%s did not panic", name)
+			}
+		}()
+		f()
+	}
+
+	check("NewComputed(nil graph)", func() { _, _ = NewComputed(nil, func(*Deps) int { return 0 }) })
+	check("NewComputed(nil fn)", func() { _, _ = NewComputed[int](NewGraph(nil), nil) })
+	check("Read(nil)", func() { Read(nil, NewSubject[int]()) })
+}
@@ -0,0 +1,48 @@
+package observer
+
+import (
+	"context"
+)
+
+// ContextObserver is an Observer that receives context of the state change.
+// Subject calls UpdateContext instead of Update on such Observer instances.
+type ContextObserver[T any] interface {
+	Observer[T]
+	UpdateContext(ctx context.Context, state T, prevState T)
+}
+
+// ContextFunc is an adapter to use ordinary function as ContextObserver.
+// Like ObserverFunc, it can only be removed through the returned Subscription.
+type ContextFunc[T any] func(ctx context.Context, state T, prevState T)
+
+// Update calls f with background context.
+func (f ContextFunc[T]) Update(state T, prevState T) {
+	f(context.Background(), state, prevState)
+}
+
+// UpdateContext calls f(ctx, state, prevState).
+func (f ContextFunc[T]) UpdateContext(ctx context.Context, state T, prevState T) {
+	f(ctx, state, prevState)
+}
+
+// SetStateContext works like SetStateErr, but passes ctx to ContextObserver instances
+// and stops notifying once ctx is done. Observer instances that were not notified
+// are listed in returned *NotifyError, which also wraps ctx error.
+// State is updated even if ctx is already done.
+// Asynchronous Subject passes ctx along with queued notifications
+// and skips those still queued once ctx is done. Skipped notifications
+// are reported to error handler as *NotifyError, since SetStateContext has returned by then.
+// Subject must be initialized before SetStateContext calls.
+func (s *Subject[T]) SetStateContext(ctx context.Context, state T) error {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	if err := s.set(ctx, state, changeSet); err != nil {
+		return err
+	}
+	return nil
+}
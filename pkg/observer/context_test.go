@@ -0,0 +1,152 @@
+package observer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestSetStateContext(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	var got any
+	s.Attach(ContextFunc[int](func(ctx context.Context, _, _ int) {
+		got = ctx.Value(ctxKey{})
+	},
+	),
+	)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "trace-id")
+	if err := s.SetStateContext(ctx, 1); err != nil {
+		t.Fatalf("SetStateContext() = %v, want nil", err)
+	}
+	if got != "trace-id" {
+		t.Fatalf("observer received context value %v, want trace-id", got)
+	}
+}
+
+func TestSetStateContextCancel(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := &testObserver{}
+	canceling := ContextFunc[int](func(context.Context, int, int) { cancel() })
+	second := &testObserver{}
+	third := &testObserver{}
+	s.Attach(first, canceling, second, third)
+
+	err := s.SetStateContext(ctx, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("SetStateContext() = %v, want %v", err, context.Canceled)
+	}
+
+	var notifyErr *NotifyError[int]
+	if !errors.As(err, &notifyErr) {
+		t.Fatalf("SetStateContext() = %#v, want *NotifyError", err)
+	}
+	if len(notifyErr.Skipped) != 2 || notifyErr.Skipped[0] != second || notifyErr.Skipped[1] != third {
+		t.Fatalf("skipped observers = %#v, want second and third", notifyErr.Skipped)
+	}
+	if len(first.states) != 1 || len(second.states) != 0 || len(third.states) != 0 {
+		t.Fatalf("notified %d, %d, %d times, want 1, 0, 0",
+			len(first.states), len(second.states), len(third.states),
+		)
+	}
+	if got := s.State(); got != 1 {
+		t.Fatalf("State() = %d, want 1", got)
+	}
+}
+
+func TestSetStateContextAsync(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithAsync[int](1, Block))
+	got := make(chan any, 1)
+	s.Attach(ContextFunc[int](func(ctx context.Context, _, _ int) {
+		got <- ctx.Value(ctxKey{})
+	},
+	),
+	)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "trace-id")
+	if err := s.SetStateContext(ctx, 1); err != nil {
+		t.Fatalf("SetStateContext() = %v, want nil", err)
+	}
+	s.Close()
+
+	if v := <-got; v != "trace-id" {
+		t.Fatalf("asynchronous observer received context value %v, want trace-id", v)
+	}
+}
+
+func TestSetStateContextAsyncCancel(t *testing.T) {
+	t.Parallel()
+
+	rec := &errorRecorder{}
+	s := NewSubject[int](WithAsync[int](2, Block), WithErrorHandler[int](rec.handle))
+	o := newGateObserver()
+	s.Attach(o)
+
+	s.SetState(1)
+	<-o.started
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.SetStateContext(ctx, 2); err != nil {
+		t.Fatalf("SetStateContext() = %v, want nil", err)
+	}
+	cancel()
+	close(o.release)
+	s.Close()
+
+	if want := [][2]int{{1, 0}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("observer received %v, want %v", o.transitions, want)
+	}
+	if rec.len() != 1 {
+		t.Fatalf("error handler received %d errors, want 1", rec.len())
+	}
+	var notifyErr *NotifyError[int]
+	if !errors.As(rec.errs[0], &notifyErr) || !errors.Is(notifyErr, context.Canceled) {
+		t.Fatalf("reported error = %#v, want *NotifyError wrapping %v", rec.errs[0], context.Canceled)
+	}
+	if len(notifyErr.Skipped) != 1 || notifyErr.Skipped[0] != o {
+		t.Fatalf("skipped observers = %#v, want the gated one", notifyErr.Skipped)
+	}
+}
+
+func TestContextFuncUpdate(t *testing.T) {
+	t.Parallel()
+
+	var got context.Context
+	f := ContextFunc[int](func(ctx context.Context, _, _ int) { got = ctx })
+	f.Update(1, 0)
+
+	if got == nil {
+		t.Fatalf("Update passed nil context")
+	}
+}
+
+// negative tests
+
+func TestSetStateContextDone(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o := &testObserver{}
+	s.Attach(o)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := s.SetStateContext(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SetStateContext() with done context = %v, want %v", err, context.Canceled)
+	}
+	if len(o.states) != 0 {
+		t.Fatalf("observer notified with done context: %v", o.states)
+	}
+}
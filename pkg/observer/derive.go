@@ -0,0 +1,121 @@
+package observer
+
+import (
+	"context"
+)
+
+// Pair holds states of two Subject instances combined by Combine.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// link is an Observer that keeps derived Subject in sync with its source.
+// Closing source Subject closes derived one as well.
+type link[A, B any] struct {
+	dst  *Subject[B]
+	next func(cur B, state A) (B, bool) // returns next derived state and whether to set it
+}
+
+// Update sets derived state computed from state of source Subject.
+func (l *link[A, B]) Update(state A, prevState A) {
+	l.UpdateContext(context.Background(), state, prevState)
+}
+
+// UpdateContext sets derived state computed from state of source Subject,
+// passing ctx to Observer instances of derived Subject.
+func (l *link[A, B]) UpdateContext(ctx context.Context, state A, _ A) {
+	l.dst.notifyMu.Lock()
+	defer l.dst.notifyMu.Unlock()
+
+	if next, ok := l.next(l.dst.State(), state); ok {
+		l.dst.reportAll(l.dst.set(ctx, next, changeSet))
+	}
+}
+
+// close closes derived Subject once source is closed.
+func (l *link[A, B]) close() {
+	l.dst.Close()
+}
+
+// follow makes dst follow src: dst state is initialized from current src state
+// and updated on every src change. Closing dst detaches it from src,
+// dst is closed right away if src is already closed.
+// src must not be changing its state in current goroutine, that would deadlock.
+func follow[A, B any](src *Subject[A], dst *Subject[B], next func(cur B, state A) (B, bool)) {
+	if src == nil {
+		panic("subject is not initialized")
+	}
+
+	l := &link[A, B]{
+		dst:  dst,
+		next: next,
+	}
+
+	// hold src state still, so no change is lost between reading it and attaching
+	src.notifyMu.Lock()
+	defer src.notifyMu.Unlock()
+
+	dst.notifyMu.Lock()
+	if state, ok := next(dst.State(), src.State()); ok {
+		dst.mu.Lock()
+		dst.state = state
+		dst.mu.Unlock()
+		dst.persist(state)
+	}
+	dst.notifyMu.Unlock()
+
+	// dst already has current src state, replaying it would set it again
+	sub, ok := src.attach([]Observer[A]{l}, withoutReplay[A]())
+	if !ok {
+		dst.Close()
+		return
+	}
+	dst.addCloseHook(sub.Unsubscribe)
+}
+
+// Map returns Subject whose state is f applied to state of src.
+// Derived Subject is updated on every change of src,
+// closing it detaches it from src, closing src closes it.
+// Options configure derived Subject.
+func Map[A, B any](src *Subject[A], f func(state A) B, opts ...Option[B]) *Subject[B] {
+	dst := NewSubject(opts...)
+	follow(src, dst, func(_ B, state A) (B, bool) {
+		return f(state), true
+	},
+	)
+	return dst
+}
+
+// Filter returns Subject that follows state of src only while pred returns true,
+// other states are skipped and derived Subject keeps the last accepted one.
+// Derived Subject starts with zero state if current state of src is not accepted.
+// Closing derived Subject detaches it from src, closing src closes it.
+// Options configure derived Subject.
+func Filter[T any](src *Subject[T], pred func(state T) bool, opts ...Option[T]) *Subject[T] {
+	dst := NewSubject(opts...)
+	follow(src, dst, func(_ T, state T) (T, bool) {
+		return state, pred(state)
+	},
+	)
+	return dst
+}
+
+// Combine returns Subject whose state pairs states of a and b.
+// Derived Subject is updated on every change of either source,
+// closing it detaches it from both, closing any source closes it.
+// Options configure derived Subject.
+func Combine[A, B any](a *Subject[A], b *Subject[B], opts ...Option[Pair[A, B]]) *Subject[Pair[A, B]] {
+	dst := NewSubject(opts...)
+	follow(a, dst, func(cur Pair[A, B], state A) (Pair[A, B], bool) {
+		cur.First = state
+		return cur, true
+	},
+	)
+	follow(b, dst, func(cur Pair[A, B], state B) (Pair[A, B], bool) {
+		cur.Second = state
+		return cur, true
+	},
+	)
+	return dst
+}
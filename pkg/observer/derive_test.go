@@ -0,0 +1,245 @@
+package observer
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	t.Parallel()
+
+	src := NewSubject[int]()
+	src.SetState(1)
+
+	dst := Map(src, strconv.Itoa)
+	if got := dst.State(); got != "1" {
+		t.Fatalf("initial derived state = %q, want %q", got, "1")
+	}
+
+	var got []string
+	dst.Attach(ObserverFunc[string](func(state, prevState string) {
+		got = append(got, prevState+"->"+state)
+	},
+	),
+	)
+	src.SetState(2)
+	src.SetState(3)
+
+	if want := []string{"1->2", "2->3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("derived observer received %v, want %v", got, want)
+	}
+}
+
+func TestMapWithOptions(t *testing.T) {
+	t.Parallel()
+
+	src := NewSubject[int]()
+	parity := Map(src, func(v int) bool { return v%2 == 0 }, WithDistinct[bool]())
+
+	var calls int
+	parity.Attach(ObserverFunc[bool](func(_, _ bool) { calls++ }))
+	for _, v := range []int{2, 4, 1, 3, 6} {
+		src.SetState(v)
+	}
+
+	// 0 (even) -> 2, 4 even, 1 odd, 3 odd, 6 even
+	if calls != 2 {
+		t.Fatalf("distinct derived observer called %d times, want 2", calls)
+	}
+}
+
+func TestMapPassesContext(t *testing.T) {
+	t.Parallel()
+
+	src := NewSubject[int]()
+	dst := Map(src, func(v int) int { return v * 2 })
+
+	var got any
+	dst.Attach(ContextFunc[int](func(ctx context.Context, _, _ int) {
+		got = ctx.Value(ctxKey{})
+	},
+	),
+	)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "trace-id")
+	if err := src.SetStateContext(ctx, 1); err != nil {
+		t.Fatalf("SetStateContext() = %v, want nil", err)
+	}
+	if got != "trace-id" {
+		t.Fatalf("derived observer received context value %v, want trace-id", got)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	src := NewSubject[int]()
+	src.SetState(-1)
+
+	positive := Filter(src, func(v int) bool { return v > 0 })
+	if got := positive.State(); got != 0 {
+		t.Fatalf("initial filtered state = %d, want 0", got)
+	}
+
+	o := &transitionObserver{}
+	positive.Attach(o)
+	for _, v := range []int{3, -2, 5, 0, 7} {
+		src.SetState(v)
+	}
+
+	if want := [][2]int{{3, 0}, {5, 3}, {7, 5}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("filtered observer received %v, want %v", o.transitions, want)
+	}
+}
+
+func TestCombine(t *testing.T) {
+	t.Parallel()
+
+	a := NewSubject[int]()
+	b := NewSubject[string]()
+	a.SetState(1)
+	b.SetState("x")
+
+	c := Combine(a, b)
+	if want := (Pair[int, string]{First: 1, Second: "x"}); c.State() != want {
+		t.Fatalf("initial combined state = %+v, want %+v", c.State(), want)
+	}
+
+	var got []Pair[int, string]
+	c.Attach(ObserverFunc[Pair[int, string]](func(state, _ Pair[int, string]) {
+		got = append(got, state)
+	},
+	),
+	)
+	a.SetState(2)
+	b.SetState("y")
+
+	want := []Pair[int, string]{{First: 2, Second: "x"}, {First: 2, Second: "y"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("combined observer received %+v, want %+v", got, want)
+	}
+}
+
+func TestCombineConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const updates = 100
+
+	a := NewSubject[int]()
+	b := NewSubject[int]()
+	c := Combine(a, b)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= updates; i++ {
+			a.SetState(i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= updates; i++ {
+			b.SetState(-i)
+		}
+	}()
+	wg.Wait()
+
+	if want := (Pair[int, int]{First: updates, Second: -updates}); c.State() != want {
+		t.Fatalf("combined state = %+v, want %+v", c.State(), want)
+	}
+}
+
+func TestDerivedClose(t *testing.T) {
+	t.Parallel()
+
+	a := NewSubject[int]()
+	b := NewSubject[int]()
+	c := Combine(a, b)
+
+	c.Close()
+
+	if len(attached(a)) != 0 || len(attached(b)) != 0 {
+		t.Fatalf("closed derived subject is still attached to its sources")
+	}
+	a.SetState(1)
+	if got := c.State(); got.First != 0 {
+		t.Fatalf("closed derived subject follows its source: %+v", got)
+	}
+}
+
+func TestSourceCloseClosesDerived(t *testing.T) {
+	t.Parallel()
+
+	a := NewSubject[int]()
+	b := NewSubject[int]()
+	c := Combine(a, b)
+	m := Map(c, func(p Pair[int, int]) int { return p.First + p.Second })
+	ch := m.Subscribe(context.Background(), 1)
+
+	a.Close()
+
+	if _, ok := receive(t, ch); ok {
+		t.Fatalf("subject derived from closed source is not closed")
+	}
+	if len(attached(b)) != 0 {
+		t.Fatalf("derived subject of closed source is still attached to other source")
+	}
+}
+
+func TestDeriveFromBehavior(t *testing.T) {
+	t.Parallel()
+
+	src := NewSubject[int](WithBehavior[int]())
+	src.SetState(1)
+	store := NewMemoryStore[int]()
+
+	dst := Map(src, func(v int) int { return v * 10 }, WithStore[int](store))
+	if _, version := dst.snapshot(); version != 0 {
+		t.Fatalf("version of fresh derived subject = %d, want 0", version)
+	}
+	if got, ok, _ := store.Load(); !ok || got != 10 {
+		t.Fatalf("stored derived state = %d, %t, want 10, true", got, ok)
+	}
+}
+
+func TestDeriveFromClosedSource(t *testing.T) {
+	t.Parallel()
+
+	a := NewSubject[int]()
+	b := NewSubject[int]()
+	a.SetState(1)
+	a.Close()
+
+	m := Map(a, func(v int) int { return v })
+	if got := m.State(); got != 1 {
+		t.Fatalf("state derived from closed source = %d, want 1", got)
+	}
+	ch := m.Subscribe(context.Background(), 1)
+	if _, ok := receive(t, ch); ok {
+		t.Fatalf("subject derived from closed source is not closed")
+	}
+
+	Combine(a, b)
+	if len(attached(b)) != 0 {
+		t.Fatalf("subject combined with closed source is still attached to other source")
+	}
+}
+
+// negative tests
+
+func TestMapNilSourceShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("Map did not panic on nil source")
+		}
+	}()
+
+	var src *Subject[int]
+	Map(src, strconv.Itoa)
+}
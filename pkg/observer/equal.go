@@ -0,0 +1,71 @@
+package observer
+
+import (
+	"context"
+	"reflect"
+)
+
+// WithEqual makes Subject skip notifications when new state
+// equals current one according to eq. Use ForceSet to notify anyway.
+func WithEqual[T any](eq func(a, b T) bool) Option[T] {
+	if eq == nil {
+		panic("equality function is nil")
+	}
+
+	return func(s *Subject[T]) {
+		s.equal = eq
+	}
+}
+
+// WithDistinct works like WithEqual with default equality of T:
+// == for comparable types and reflect.DeepEqual for the rest.
+func WithDistinct[T any]() Option[T] {
+	return WithEqual(defaultEqual[T]())
+}
+
+// defaultEqual returns equality function for T.
+// Interface types are compared with reflect.DeepEqual,
+// since == panics on their non-comparable dynamic values.
+func defaultEqual[T any]() func(a, b T) bool {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Comparable() && !hasInterface(t) {
+		return func(a, b T) bool {
+			return any(a) == any(b)
+		}
+	}
+
+	return func(a, b T) bool {
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// hasInterface reports whether values of type t may hold interfaces.
+func hasInterface(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Array:
+		return hasInterface(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasInterface(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ForceSet updates current Subject state and notifies all attached Observer instances
+// even if new state equals current one.
+// Subject must be initialized before ForceSet calls.
+func (s *Subject[T]) ForceSet(state T) {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.reportAll(s.set(context.Background(), state, changeForce))
+}
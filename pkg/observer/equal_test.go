@@ -0,0 +1,91 @@
+package observer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithDistinct(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithDistinct[int]())
+	o := &transitionObserver{}
+	s.Attach(o)
+
+	for _, state := range []int{4, 4, -2, -2, 4} {
+		s.SetState(state)
+	}
+
+	if want := [][2]int{{4, 0}, {-2, 4}, {4, -2}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("observer received %v, want %v", o.transitions, want)
+	}
+}
+
+func TestWithDistinctNonComparable(t *testing.T) {
+	t.Parallel()
+
+	type state struct {
+		tags []string
+		meta any
+	}
+
+	s := NewSubject[state](WithDistinct[state]())
+	var calls int
+	s.Attach(ObserverFunc[state](func(_, _ state) { calls++ }))
+
+	s.SetState(state{tags: []string{"a"}, meta: []int{1}})
+	s.SetState(state{tags: []string{"a"}, meta: []int{1}})
+	s.SetState(state{tags: []string{"b"}, meta: []int{1}})
+
+	if calls != 2 {
+		t.Fatalf("observer called %d times, want 2", calls)
+	}
+}
+
+func TestWithEqual(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[string](WithEqual(strings.EqualFold))
+	var calls int
+	s.Attach(ObserverFunc[string](func(_, _ string) { calls++ }))
+
+	s.SetState("go")
+	s.SetState("GO")
+	s.Update(func(cur string) string { return strings.ToLower(cur) })
+
+	if calls != 1 {
+		t.Fatalf("observer called %d times, want 1", calls)
+	}
+	if got := s.State(); got != "go" {
+		t.Fatalf("State() = %q, want unchanged %q", got, "go")
+	}
+}
+
+func TestForceSet(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithDistinct[int]())
+	o := &transitionObserver{}
+	s.Attach(o)
+
+	s.SetState(1)
+	s.ForceSet(1)
+
+	if want := [][2]int{{1, 0}, {1, 1}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("observer received %v, want %v", o.transitions, want)
+	}
+}
+
+// negative tests
+
+func TestWithEqualNilShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic on nil equality function, got none")
+		}
+	}()
+	WithEqual[int](nil)
+}
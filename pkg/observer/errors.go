@@ -0,0 +1,173 @@
+package observer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrorHandler receives failures of Observer instances and Store.
+// Failures of Observer instances are reported as *ObserverError,
+// failures of Store as *StoreError. Asynchronous notifications
+// skipped because their context is done are reported as *NotifyError.
+type ErrorHandler func(err error)
+
+// ObserverError describes failure of a single Observer or FallibleObserver
+// while it was notified about Event.
+type ObserverError[T any] struct {
+	Observer any
+	Event    Event[T]
+	Err      error
+}
+
+// Error implements error interface.
+func (e *ObserverError[T]) Error() string {
+	return fmt.Sprintf("observer %T failed on version %d: %v", e.Observer, e.Event.Version, e.Err)
+}
+
+// Unwrap returns underlying error.
+func (e *ObserverError[T]) Unwrap() error {
+	return e.Err
+}
+
+// NotifyError joins failures of Observer instances
+// notified about a single state change.
+// Skipped lists Observer instances that were not notified
+// because context was done, Err holds the context error then.
+type NotifyError[T any] struct {
+	Errors  []*ObserverError[T]
+	Skipped []any
+	Err     error
+}
+
+// Error implements error interface, failures are listed one per line.
+func (e *NotifyError[T]) Error() string {
+	msgs := make([]string, 0, len(e.Errors)+1)
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	if len(e.Skipped) > 0 {
+		msgs = append(msgs, fmt.Sprintf("%d observers skipped: %v", len(e.Skipped), e.Err))
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns joined failures and context error.
+func (e *NotifyError[T]) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors)+1)
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// Is reports whether any of joined failures or context error matches target,
+// so errors.Is walks NotifyError before Go 1.20 too.
+func (e *NotifyError[T]) Is(target error) bool {
+	for _, err := range e.Unwrap() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of joined failures or context error that matches target,
+// so errors.As walks NotifyError before Go 1.20 too.
+func (e *NotifyError[T]) As(target any) bool {
+	for _, err := range e.Unwrap() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// PanicError holds value recovered from panicking Observer
+// and stack trace of the panic.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// Error implements error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// WithErrorHandler makes Subject report failures of Observer instances to h.
+func WithErrorHandler[T any](h ErrorHandler) Option[T] {
+	return func(s *Subject[T]) {
+		s.onError = h
+	}
+}
+
+// WithRecover makes Subject recover panics of Observer instances,
+// so remaining Observer instances are still notified
+// and panic does not reach the caller that changed state.
+// Every recovered panic is reported to h, if it is not nil,
+// as *ObserverError wrapping *PanicError (SetStateErr returns it instead).
+// Nil h keeps handler set WithErrorHandler.
+func WithRecover[T any](h ErrorHandler) Option[T] {
+	return func(s *Subject[T]) {
+		s.recover = true
+		if h != nil {
+			s.onError = h
+		}
+	}
+}
+
+// WithPanicLimit makes Subject created WithRecover
+// detach Observer once it panics n times.
+func WithPanicLimit[T any](n int) Option[T] {
+	if n < 1 {
+		panic("panic limit must be positive")
+	}
+
+	return func(s *Subject[T]) {
+		s.panicLimit = uint32(n)
+	}
+}
+
+// panicked wraps recovered panic of sub Observer
+// and detaches it once panic limit is reached.
+func (s *Subject[T]) panicked(sub *subscriber[T], e Event[T], err *PanicError) *ObserverError[T] {
+	if s.instrument != nil {
+		s.instrument.Panicked(sub.source())
+	}
+	if s.panicLimit > 0 && atomic.AddUint32(&sub.panics, 1) >= s.panicLimit {
+		s.remove(func(other *subscriber[T]) bool { return other == sub })
+	}
+
+	return &ObserverError[T]{
+		Observer: sub.source(),
+		Event:    e,
+		Err:      err,
+	}
+}
+
+// report passes err to error handler of current Subject, if any.
+func (s *Subject[T]) report(err *ObserverError[T]) {
+	s.fail(err)
+}
+
+// fail passes any err to error handler of current Subject, if any.
+func (s *Subject[T]) fail(err error) {
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// reportAll reports every failure joined in err.
+func (s *Subject[T]) reportAll(err *NotifyError[T]) {
+	if err == nil {
+		return
+	}
+	for _, obsErr := range err.Errors {
+		s.report(obsErr)
+	}
+}
@@ -0,0 +1,194 @@
+package observer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// errorRecorder is a helper ErrorHandler that stores every reported error.
+type errorRecorder struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (r *errorRecorder) handle(err error) {
+	r.mu.Lock()
+	r.errs = append(r.errs, err)
+	r.mu.Unlock()
+}
+
+func (r *errorRecorder) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.errs)
+}
+
+var panicking = ObserverFunc[int](func(state, _ int) {
+	panic("boom")
+},
+)
+
+func TestWithRecover(t *testing.T) {
+	t.Parallel()
+
+	rec := &errorRecorder{}
+	s := NewSubject[int](WithRecover[int](rec.handle))
+	after := &testObserver{}
+	s.Attach(panicking, after)
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("panic of observer reached SetState caller: %v", r)
+		}
+	}()
+	s.SetState(1)
+
+	if got, ok := after.lastState(t); !ok || got != 1 {
+		t.Fatalf("observer after panicking one received %d, want 1", got)
+	}
+	if rec.len() != 1 {
+		t.Fatalf("error handler received %d errors, want 1", rec.len())
+	}
+
+	var obsErr *ObserverError[int]
+	if !errors.As(rec.errs[0], &obsErr) {
+		t.Fatalf("reported error %T is not *ObserverError[int]", rec.errs[0])
+	}
+	if obsErr.Event.State != 1 || obsErr.Event.PrevState != 0 {
+		t.Fatalf("reported event = %+v, want state 1 after 0", obsErr.Event)
+	}
+
+	var panicErr *PanicError
+	if !errors.As(rec.errs[0], &panicErr) {
+		t.Fatalf("reported error %v does not wrap *PanicError", rec.errs[0])
+	}
+	if panicErr.Value != "boom" {
+		t.Fatalf("recovered value = %v, want boom", panicErr.Value)
+	}
+	if !strings.Contains(string(panicErr.Stack), "errors_test.go") {
+		t.Fatalf("stack trace does not point to panicking observer:\n%s", panicErr.Stack)
+	}
+}
+
+func TestWithRecoverNilKeepsErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	rec := &errorRecorder{}
+	s := NewSubject[int](WithErrorHandler[int](rec.handle), WithRecover[int](nil))
+	s.Attach(panicking)
+
+	s.SetState(1)
+
+	if rec.len() != 1 {
+		t.Fatalf("error handler received %d errors, want 1", rec.len())
+	}
+}
+
+func TestNotifyErrorIsAs(t *testing.T) {
+	t.Parallel()
+
+	err := &NotifyError[int]{
+		Errors: []*ObserverError[int]{{Err: &PanicError{Value: "boom"}}, {Err: errDiskFull}},
+		Err:    context.Canceled,
+	}
+
+	// methods are called directly, errors.Is and errors.As
+	// only walk Unwrap() []error since Go 1.20
+	for _, target := range []error{errDiskFull, context.Canceled} {
+		if !err.Is(target) {
+			t.Fatalf("Is(%v) = false, want true", target)
+		}
+	}
+	if err.Is(context.DeadlineExceeded) {
+		t.Fatalf("Is(%v) = true, want false", context.DeadlineExceeded)
+	}
+
+	var panicErr *PanicError
+	if !err.As(&panicErr) || panicErr.Value != "boom" {
+		t.Fatalf("As(*PanicError) = %v, want recovered boom", panicErr)
+	}
+	var obsErr *ObserverError[int]
+	if !err.As(&obsErr) || obsErr != err.Errors[0] {
+		t.Fatalf("As(*ObserverError) = %v, want the first failure", obsErr)
+	}
+	var storeErr *StoreError
+	if err.As(&storeErr) {
+		t.Fatalf("As(*StoreError) = true, want false")
+	}
+}
+
+func TestWithPanicLimit(t *testing.T) {
+	t.Parallel()
+
+	rec := &errorRecorder{}
+	s := NewSubject[int](WithRecover[int](rec.handle), WithPanicLimit[int](2))
+	other := &testObserver{}
+	s.Attach(panicking, other)
+
+	for i := 1; i <= 4; i++ {
+		s.SetState(i)
+	}
+
+	if rec.len() != 2 {
+		t.Fatalf("error handler received %d errors, want 2", rec.len())
+	}
+	if got := attached(s); len(got) != 1 || got[0] != Observer[int](other) {
+		t.Fatalf("observers after panic limit = %#v, want only non-panicking one", got)
+	}
+	if len(other.states) != 4 {
+		t.Fatalf("non-panicking observer received %d updates, want 4", len(other.states))
+	}
+}
+
+func TestWithRecoverAsync(t *testing.T) {
+	t.Parallel()
+
+	rec := &errorRecorder{}
+	s := NewSubject[int](WithAsync[int](4, Block), WithRecover[int](rec.handle), WithPanicLimit[int](1))
+	other := &testObserver{}
+	s.Attach(panicking, other)
+
+	s.SetState(1)
+	eventually(t, time.Second, func() bool { return len(attached(s)) == 1 })
+	s.SetState(2)
+	s.Close()
+
+	if rec.len() != 1 {
+		t.Fatalf("error handler received %d errors, want 1", rec.len())
+	}
+	if len(other.states) != 2 {
+		t.Fatalf("non-panicking observer received %d updates, want 2", len(other.states))
+	}
+}
+
+// negative tests
+
+func TestWithoutRecoverShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	s.Attach(panicking)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic of observer to reach SetState caller, got none")
+		}
+	}()
+	s.SetState(1)
+}
+
+func TestWithPanicLimitZeroShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic on zero panic limit, got none")
+		}
+	}()
+	WithPanicLimit[int](0)
+}
@@ -0,0 +1,68 @@
+package observer
+
+import (
+	"context"
+)
+
+// FallibleObserver is an Observer whose Update may fail.
+// Failures are returned by SetStateErr, state changes made in other ways
+// report them to ErrorHandler of Subject, see WithErrorHandler.
+type FallibleObserver[T any] interface {
+	Update(state T, prevState T) error
+}
+
+// FallibleFunc is an adapter to use ordinary function as FallibleObserver.
+type FallibleFunc[T any] func(state T, prevState T) error
+
+// Update returns f(state, prevState).
+func (f FallibleFunc[T]) Update(state T, prevState T) error {
+	return f(state, prevState)
+}
+
+// fallible lets FallibleObserver be attached alongside Observer instances.
+type fallible[T any] struct {
+	observer FallibleObserver[T]
+}
+
+// Update calls wrapped FallibleObserver, error is handled by Subject.
+func (f *fallible[T]) Update(state T, prevState T) {
+	_ = f.observer.Update(state, prevState)
+}
+
+// AttachFallible adds new FallibleObserver instances to current Subject and returns
+// Subscription that detaches exactly these instances.
+// Subject must be initialized before AttachFallible calls.
+func (s *Subject[T]) AttachFallible(observers ...FallibleObserver[T]) Subscription {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	wrapped := make([]Observer[T], 0, len(observers))
+	for _, observer := range observers {
+		wrapped = append(wrapped, &fallible[T]{observer: observer})
+	}
+
+	sub, _ := s.attach(wrapped)
+	sub.replay()
+	return sub
+}
+
+// SetStateErr works like SetState, but returns failures of Observer instances
+// instead of reporting them to ErrorHandler. Every Observer is notified
+// even if some of them fail, returned error is *NotifyError listing failed ones.
+// Asynchronous Subject reports failures of its Observer instances to ErrorHandler,
+// since they happen after SetStateErr returns.
+// Subject must be initialized before SetStateErr calls.
+func (s *Subject[T]) SetStateErr(state T) error {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	if err := s.set(context.Background(), state, changeSet); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package observer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var errDiskFull = errors.New("disk full")
+
+func TestSetStateErr(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	ok := &testObserver{}
+	failing := FallibleFunc[int](func(state, _ int) error {
+		if state > 1 {
+			return errDiskFull
+		}
+		return nil
+	},
+	)
+	s.AttachFallible(failing)
+	s.Attach(ok)
+
+	if err := s.SetStateErr(1); err != nil {
+		t.Fatalf("SetStateErr(1) = %v, want nil", err)
+	}
+
+	err := s.SetStateErr(2)
+	if err == nil {
+		t.Fatalf("SetStateErr(2) = nil, want error")
+	}
+	if !errors.Is(err, errDiskFull) {
+		t.Fatalf("SetStateErr(2) = %v, does not wrap %v", err, errDiskFull)
+	}
+
+	var notifyErr *NotifyError[int]
+	if !errors.As(err, &notifyErr) || len(notifyErr.Errors) != 1 {
+		t.Fatalf("SetStateErr(2) = %#v, want *NotifyError with 1 failure", err)
+	}
+	if got := notifyErr.Errors[0].Event.State; got != 2 {
+		t.Fatalf("failure reported for state %d, want 2", got)
+	}
+	if reflect.ValueOf(notifyErr.Errors[0].Observer).Pointer() != reflect.ValueOf(failing).Pointer() {
+		t.Fatalf("failure reported for observer %#v, want the failing one", notifyErr.Errors[0].Observer)
+	}
+
+	// observer attached after failing one must still be notified
+	if got, _ := ok.lastState(t); got != 2 {
+		t.Fatalf("observer after failing one received %d, want 2", got)
+	}
+}
+
+func TestSetStateErrJoinsFailures(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithRecover[int](nil))
+	s.AttachFallible(
+		FallibleFunc[int](func(_, _ int) error { return errDiskFull }),
+		FallibleFunc[int](func(_, _ int) error { return errors.New("read-only") }),
+	)
+	s.Attach(panicking)
+
+	err := s.SetStateErr(1)
+
+	var notifyErr *NotifyError[int]
+	if !errors.As(err, &notifyErr) || len(notifyErr.Errors) != 3 {
+		t.Fatalf("SetStateErr(1) = %v, want 3 failures", err)
+	}
+	for _, want := range []string{"disk full", "read-only", "panic: boom"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("SetStateErr(1) = %q, does not mention %q", err, want)
+		}
+	}
+	var panicErr *PanicError
+	if !errors.As(notifyErr.Errors[2], &panicErr) {
+		t.Fatalf("third failure %v does not wrap *PanicError", notifyErr.Errors[2])
+	}
+}
+
+func TestFallibleErrorReported(t *testing.T) {
+	t.Parallel()
+
+	rec := &errorRecorder{}
+	s := NewSubject[int](WithErrorHandler[int](rec.handle))
+	s.AttachFallible(FallibleFunc[int](func(_, _ int) error { return errDiskFull }))
+
+	s.SetState(1)
+
+	if rec.len() != 1 || !errors.Is(rec.errs[0], errDiskFull) {
+		t.Fatalf("error handler received %v, want %v", rec.errs, errDiskFull)
+	}
+}
+
+func TestAttachFallibleUnsubscribe(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	var calls int
+	sub := s.AttachFallible(FallibleFunc[int](func(_, _ int) error {
+		calls++
+		return nil
+	},
+	),
+	)
+
+	s.SetState(1)
+	sub.Unsubscribe()
+	s.SetState(2)
+
+	if calls != 1 {
+		t.Fatalf("fallible observer called %d times, want 1", calls)
+	}
+}
+
+// negative tests
+
+func TestSetStateErrWithoutRecoverShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	s.Attach(panicking)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic of observer to reach SetStateErr caller, got none")
+		}
+	}()
+	_ = s.SetStateErr(1)
+}
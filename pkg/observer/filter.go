@@ -0,0 +1,33 @@
+package observer
+
+// WithFilter makes attached Observer receive only state changes
+// for which pred returns true. Several filters must all pass.
+func WithFilter[T any](pred func(state T, prevState T) bool) AttachOption[T] {
+	if pred == nil {
+		panic("filter is nil")
+	}
+
+	return func(sub *subscriber[T]) {
+		if prev := sub.filter; prev != nil {
+			sub.filter = func(state T, prevState T) bool {
+				return prev(state, prevState) && pred(state, prevState)
+			}
+			return
+		}
+		sub.filter = pred
+	}
+}
+
+// WithKey makes attached Observer receive only state changes
+// that change the projection of state selected by key,
+// e.g. a single field of a large struct.
+func WithKey[T any, K comparable](key func(state T) K) AttachOption[T] {
+	if key == nil {
+		panic("key selector is nil")
+	}
+
+	return WithFilter(func(state T, prevState T) bool {
+		return key(state) != key(prevState)
+	},
+	)
+}
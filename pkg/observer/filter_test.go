@@ -0,0 +1,86 @@
+package observer
+
+import (
+	"reflect"
+	"testing"
+)
+
+type profile struct {
+	name  string
+	email string
+	tags  []string
+}
+
+func TestWithKey(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[profile]()
+	var names []string
+	s.AttachWith(ObserverFunc[profile](func(state, _ profile) {
+		names = append(names, state.name)
+	},
+	), WithKey(func(p profile) string { return p.name }),
+	)
+
+	s.SetState(profile{name: "ann"})
+	s.SetState(profile{name: "ann", email: "ann@example.com"})
+	s.SetState(profile{name: "ann", email: "ann@example.com", tags: []string{"admin"}})
+	s.SetState(profile{name: "bob", email: "ann@example.com"})
+
+	if want := []string{"ann", "bob"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("keyed observer received %v, want %v", names, want)
+	}
+}
+
+func TestWithFilter(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o := &transitionObserver{}
+	increasing := WithFilter(func(state, prevState int) bool { return state > prevState })
+	even := WithFilter(func(state, _ int) bool { return state%2 == 0 })
+	s.AttachWith(o, increasing, even)
+
+	for _, state := range []int{2, 1, 3, 4, 6, 5} {
+		s.SetState(state)
+	}
+
+	if want := [][2]int{{2, 0}, {4, 3}, {6, 4}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("filtered observer received %v, want %v", o.transitions, want)
+	}
+}
+
+func TestWithFilterAsync(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithAsync[int](1, Block))
+	o := &testObserver{}
+	s.AttachWith(o, WithFilter(func(state, _ int) bool { return state > 5 }))
+
+	for i := 1; i <= 10; i++ {
+		s.SetState(i)
+	}
+	s.Close()
+
+	if want := []int{6, 7, 8, 9, 10}; !reflect.DeepEqual(o.states, want) {
+		t.Fatalf("filtered observer received %v, want %v", o.states, want)
+	}
+}
+
+// negative tests
+
+func TestWithFilterNilShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	check := func(name string, f func()) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("%s did not panic on nil function", name)
+			}
+		}()
+		f()
+	}
+
+	check("WithFilter", func() { WithFilter[int](nil) })
+	check("WithKey", func() { WithKey[int, int](nil) })
+}
@@ -0,0 +1,88 @@
+package observer
+
+import (
+	"context"
+)
+
+// WithHistory makes Subject keep up to n previous states, so changes
+// can be reverted with Undo and reapplied with Redo.
+func WithHistory[T any](n int) Option[T] {
+	if n < 1 {
+		panic("history size must be positive")
+	}
+
+	return func(s *Subject[T]) {
+		s.historySize = n
+	}
+}
+
+// remember keeps state that was just replaced for Undo
+// and discards states that could be redone.
+// Caller must hold mu.
+func (s *Subject[T]) remember(prevState T) {
+	if s.historySize == 0 {
+		return
+	}
+
+	if len(s.undo) == s.historySize {
+		s.undo = append(s.undo[:0], s.undo[1:]...)
+	}
+	s.undo = append(s.undo, prevState)
+	s.redo = s.redo[:0]
+}
+
+// Undo reverts the last state change and notifies Observer instances about it.
+// Undo reports false if there is nothing to undo or Subject has no history.
+// Subject must be initialized before Undo calls.
+func (s *Subject[T]) Undo() bool {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	return s.travel(&s.undo, &s.redo)
+}
+
+// Redo reapplies the last state change reverted by Undo and notifies Observer instances.
+// Redo reports false if there is nothing to redo, any state change
+// other than Undo and Redo discards states that could be redone.
+// Subject must be initialized before Redo calls.
+func (s *Subject[T]) Redo() bool {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	return s.travel(&s.redo, &s.undo)
+}
+
+// travel moves Subject to the last state of from, keeping current state in to.
+func (s *Subject[T]) travel(from, to *[]T) bool {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.mu.Lock()
+	if len(*from) == 0 {
+		s.mu.Unlock()
+		return false
+	}
+	state := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, s.state)
+	s.mu.Unlock()
+
+	s.reportAll(s.set(context.Background(), state, changeHistory))
+	return true
+}
+
+// History returns previous states of Subject that Undo can return to,
+// the oldest first.
+// Subject must be initialized before History calls.
+func (s *Subject[T]) History() []T {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]T(nil), s.undo...)
+}
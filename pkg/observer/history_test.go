@@ -0,0 +1,118 @@
+package observer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUndoRedo(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithHistory[int](10))
+	o := &transitionObserver{}
+	s.Attach(o)
+
+	s.SetState(1)
+	s.SetState(2)
+	if !s.Undo() || !s.Undo() {
+		t.Fatalf("Undo() = false, want true")
+	}
+	if !s.Redo() {
+		t.Fatalf("Redo() = false, want true")
+	}
+
+	want := [][2]int{{1, 0}, {2, 1}, {1, 2}, {0, 1}, {1, 0}}
+	if !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("observer received %v, want %v", o.transitions, want)
+	}
+	if got := s.State(); got != 1 {
+		t.Fatalf("State() = %d, want 1", got)
+	}
+	if got, want := s.History(), []int{0}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("History() = %v, want %v", got, want)
+	}
+}
+
+func TestSetStateDiscardsRedo(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithHistory[int](10))
+	s.SetState(1)
+	s.SetState(2)
+	s.Undo()
+	s.SetState(3)
+
+	if s.Redo() {
+		t.Fatalf("Redo() after SetState = true, want false")
+	}
+	if got, want := s.History(), []int{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("History() = %v, want %v", got, want)
+	}
+}
+
+func TestHistoryBound(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithHistory[int](2))
+	for i := 1; i <= 5; i++ {
+		s.SetState(i)
+	}
+
+	if got, want := s.History(), []int{3, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("History() = %v, want %v", got, want)
+	}
+	s.Undo()
+	s.Undo()
+	if s.Undo() {
+		t.Fatalf("Undo() past history bound = true, want false")
+	}
+	if got := s.State(); got != 3 {
+		t.Fatalf("State() = %d, want 3", got)
+	}
+}
+
+func TestHistoryBatch(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithHistory[int](10))
+	_ = s.Batch(func(tx *Tx[int]) error {
+		for i := 1; i <= 3; i++ {
+			tx.Set(i)
+		}
+		return nil
+	},
+	)
+
+	if got, want := s.History(), []int{0}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("History() after Batch = %v, want %v", got, want)
+	}
+}
+
+// negative tests
+
+func TestUndoWithoutHistory(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o := &testObserver{}
+	s.Attach(o)
+	s.SetState(1)
+
+	if s.Undo() || s.Redo() {
+		t.Fatalf("Undo/Redo of subject without history reported true")
+	}
+	if len(o.states) != 1 || len(s.History()) != 0 {
+		t.Fatalf("subject without history changed: states %v, history %v", o.states, s.History())
+	}
+}
+
+func TestWithHistoryInvalidSizeShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("WithHistory(0) did not panic")
+		}
+	}()
+	WithHistory[int](0)
+}
@@ -0,0 +1,105 @@
+package observer
+
+import (
+	"expvar"
+	"fmt"
+	"time"
+)
+
+// Instrumentation receives metrics of Subject.
+// Methods are called synchronously from Subject internals,
+// so they must be fast and safe for concurrent use,
+// and must not change state of the Subject they instrument.
+type Instrumentation interface {
+	// Attached is called when n Observer instances are attached.
+	Attached(n int)
+	// Detached is called when n Observer instances are detached,
+	// including ones detached by Close.
+	Detached(n int)
+	// Notified is called once Observer has handled a notification.
+	Notified(observer any, latency time.Duration)
+	// Fired is called once a state change is dispatched to all Observer instances.
+	// For asynchronous Subject latency covers queueing only.
+	Fired(latency time.Duration)
+	// Panicked is called when Subject created WithRecover recovers a panic of Observer.
+	Panicked(observer any)
+	// Dropped is called when asynchronous Subject drops or coalesces n
+	// notifications of Observer, because its queue is full or it was detached.
+	Dropped(observer any, n int)
+}
+
+// WithInstrumentation makes Subject report its metrics to i.
+func WithInstrumentation[T any](i Instrumentation) Option[T] {
+	if i == nil {
+		panic("instrumentation is nil")
+	}
+
+	return func(s *Subject[T]) {
+		s.instrument = i
+	}
+}
+
+// Expvar is an Instrumentation that publishes metrics with expvar package.
+// Metrics are kept in expvar.Map with following keys:
+//   - observers: number of currently attached Observer instances
+//   - attached, detached: total number of attached and detached Observer instances
+//   - fired, fired_ns: number of state changes and total time spent dispatching them
+//   - notified, notified_ns: number of notifications and total time spent by Observer instances
+//   - latency_ns: map of total time spent by Observer instances, by their type
+//   - panics: number of recovered panics
+//   - dropped: number of dropped asynchronous notifications
+//
+// A single Expvar may instrument several Subject instances, metrics are summed then.
+type Expvar struct {
+	vars    *expvar.Map
+	latency *expvar.Map
+}
+
+// NewExpvar creates Expvar and publishes its metrics under name.
+// Like expvar.Publish, NewExpvar panics if name is already registered.
+func NewExpvar(name string) *Expvar {
+	e := &Expvar{
+		vars:    expvar.NewMap(name),
+		latency: new(expvar.Map).Init(),
+	}
+	e.vars.Set("latency_ns", e.latency)
+	for _, key := range []string{"observers", "attached", "detached", "fired", "fired_ns", "notified", "notified_ns", "panics", "dropped"} {
+		e.vars.Add(key, 0)
+	}
+	return e
+}
+
+// Attached implements Instrumentation interface.
+func (e *Expvar) Attached(n int) {
+	e.vars.Add("observers", int64(n))
+	e.vars.Add("attached", int64(n))
+}
+
+// Detached implements Instrumentation interface.
+func (e *Expvar) Detached(n int) {
+	e.vars.Add("observers", -int64(n))
+	e.vars.Add("detached", int64(n))
+}
+
+// Notified implements Instrumentation interface.
+func (e *Expvar) Notified(observer any, latency time.Duration) {
+	e.vars.Add("notified", 1)
+	e.vars.Add("notified_ns", int64(latency))
+	e.latency.Add(fmt.Sprintf("%T", observer), int64(latency))
+}
+
+// Fired implements Instrumentation interface.
+func (e *Expvar) Fired(latency time.Duration) {
+	e.vars.Add("fired", 1)
+	e.vars.Add("fired_ns", int64(latency))
+}
+
+// Panicked implements Instrumentation interface.
+func (e *Expvar) Panicked(any) {
+	e.vars.Add("panics", 1)
+}
+
+// Dropped implements Instrumentation interface.
+func (e *Expvar) Dropped(_ any, n int) {
+	e.vars.Add("dropped", int64(n))
+}
@@ -0,0 +1,142 @@
+package observer
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// instrumentRecorder is a helper Instrumentation that counts every call.
+type instrumentRecorder struct {
+	mu                                sync.Mutex
+	attached, detached, fired, panics int
+	dropped                           int
+	notified                          int
+	latency                           time.Duration
+}
+
+func newInstrumentRecorder() *instrumentRecorder {
+	return &instrumentRecorder{}
+}
+
+func (r *instrumentRecorder) Attached(n int) {
+	r.mu.Lock()
+	r.attached += n
+	r.mu.Unlock()
+}
+
+func (r *instrumentRecorder) Detached(n int) {
+	r.mu.Lock()
+	r.detached += n
+	r.mu.Unlock()
+}
+
+func (r *instrumentRecorder) Notified(_ any, latency time.Duration) {
+	r.mu.Lock()
+	r.notified++
+	r.latency += latency
+	r.mu.Unlock()
+}
+
+func (r *instrumentRecorder) Fired(time.Duration) {
+	r.mu.Lock()
+	r.fired++
+	r.mu.Unlock()
+}
+
+func (r *instrumentRecorder) Panicked(any) {
+	r.mu.Lock()
+	r.panics++
+	r.mu.Unlock()
+}
+
+func (r *instrumentRecorder) Dropped(_ any, n int) {
+	r.mu.Lock()
+	r.dropped += n
+	r.mu.Unlock()
+}
+
+func TestWithInstrumentation(t *testing.T) {
+	t.Parallel()
+
+	r := newInstrumentRecorder()
+	s := NewSubject[int](WithInstrumentation[int](r), WithRecover[int](nil))
+	slow := ObserverFunc[int](func(int, int) { time.Sleep(time.Millisecond) })
+	o := &testObserver{}
+	sub := s.Attach(o, slow)
+	s.AttachWith(panicking)
+
+	s.SetState(1)
+	s.SetState(2)
+	sub.Unsubscribe()
+	s.Close()
+
+	if r.attached != 3 || r.detached != 3 {
+		t.Fatalf("attached %d, detached %d, want 3 and 3", r.attached, r.detached)
+	}
+	if r.fired != 2 || r.notified != 6 || r.panics != 2 {
+		t.Fatalf("fired %d, notified %d, panics %d, want 2, 6 and 2", r.fired, r.notified, r.panics)
+	}
+	if r.latency < 2*time.Millisecond {
+		t.Fatalf("total latency %v, want at least 2ms", r.latency)
+	}
+}
+
+func TestInstrumentationDropped(t *testing.T) {
+	t.Parallel()
+
+	r := newInstrumentRecorder()
+	s := NewSubject[int](WithInstrumentation[int](r), WithAsync[int](2, DropNewest))
+	o := newGateObserver()
+	s.Attach(o)
+
+	// state 1 is being delivered, 2 and 3 are queued, 4 and 5 are dropped
+	fillBehindGate(t, s, o, 5)
+	s.Detach(o)
+	close(o.release)
+	s.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.dropped != 4 {
+		t.Fatalf("dropped %d notifications, want 4", r.dropped)
+	}
+}
+
+var expvarRuns int64
+
+func TestExpvar(t *testing.T) {
+	t.Parallel()
+
+	// expvar names are global, every run of the test needs its own
+	name := fmt.Sprintf("observer_test_%d", atomic.AddInt64(&expvarRuns, 1))
+	s := NewSubject[int](WithInstrumentation[int](NewExpvar(name)))
+	s.Attach(&testObserver{})
+	s.SetState(1)
+
+	vars := expvar.Get(name).(*expvar.Map)
+	for key, want := range map[string]string{"observers": "1", "attached": "1", "fired": "1", "notified": "1", "dropped": "0"} {
+		if got := vars.Get(key).String(); got != want {
+			t.Fatalf("%s = %s, want %s", key, got, want)
+		}
+	}
+	if got := vars.Get("latency_ns").(*expvar.Map).Get("*observer.testObserver"); got == nil {
+		t.Fatalf("latency of *observer.testObserver is not published")
+	}
+}
+
+// negative tests
+
+func TestWithInstrumentationNilShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("WithInstrumentation(nil) did not panic")
+		}
+	}()
+	WithInstrumentation[int](nil)
+}
@@ -0,0 +1,154 @@
+package observer
+
+import (
+	"sync"
+	"time"
+)
+
+// Limited is an Observer wrapper that limits how often wrapped Observer is notified.
+// Suppressed state changes are merged: wrapped Observer receives the latest state
+// with prevState of the first suppressed change, so it sees a consistent transition.
+// The latest state is always delivered eventually (trailing edge),
+// even if Limited is detached or its Subject is closed meanwhile.
+// Wrapped Observer is called from Update or from Clock timer goroutine,
+// never concurrently.
+type Limited[T any] struct {
+	observer Observer[T]
+	interval time.Duration
+	debounce bool // wait for a quiet interval instead of limiting rate
+	clock    Clock
+
+	mu        sync.Mutex // protects fields below and serializes wrapped Observer calls
+	timer     Timer      // nil once interval is over
+	gen       uint64     // generation of timer, stale timers are ignored
+	pending   bool
+	state     T
+	prevState T
+}
+
+// LimitOption configures Observer wrappers created by Throttle and Debounce.
+type LimitOption func(*limitConfig)
+
+type limitConfig struct {
+	clock Clock
+}
+
+// WithLimitClock makes Observer wrapper measure intervals with c instead of SystemClock.
+func WithLimitClock(c Clock) LimitOption {
+	if c == nil {
+		panic("clock is nil")
+	}
+
+	return func(cfg *limitConfig) {
+		cfg.clock = c
+	}
+}
+
+// Throttle wraps o, so it is notified at most once per interval.
+// The first change is delivered right away,
+// changes made during the interval are delivered as one at its end.
+func Throttle[T any](o Observer[T], interval time.Duration, opts ...LimitOption) *Limited[T] {
+	return limit(o, interval, false, opts)
+}
+
+// Debounce wraps o, so it is notified only once changes stop for wait.
+// Changes made in a row are delivered as one.
+func Debounce[T any](o Observer[T], wait time.Duration, opts ...LimitOption) *Limited[T] {
+	return limit(o, wait, true, opts)
+}
+
+// limit creates Limited with given mode.
+func limit[T any](o Observer[T], interval time.Duration, debounce bool, opts []LimitOption) *Limited[T] {
+	if o == nil {
+		panic("observer is nil")
+	}
+	if interval <= 0 {
+		panic("interval must be positive")
+	}
+
+	cfg := limitConfig{clock: SystemClock}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return &Limited[T]{
+		observer: o,
+		interval: interval,
+		debounce: debounce,
+		clock:    cfg.clock,
+	}
+}
+
+// Update implements Observer interface.
+func (l *Limited[T]) Update(state, prevState T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.pending {
+		l.prevState = prevState
+	}
+	l.state = state
+	l.pending = true
+
+	switch {
+	case l.debounce:
+		l.schedule()
+	case l.timer == nil:
+		l.flush()
+		l.schedule()
+	}
+}
+
+// Flush notifies wrapped Observer about suppressed changes right away, if any.
+func (l *Limited[T]) Flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flush()
+}
+
+// schedule starts new interval, replacing the current one.
+// Caller must hold mu.
+func (l *Limited[T]) schedule() {
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+
+	l.gen++
+	gen := l.gen
+	l.timer = l.clock.AfterFunc(l.interval, func() {
+		l.fire(gen)
+	},
+	)
+}
+
+// fire ends interval of given generation and delivers suppressed changes.
+// Throttle starts next interval if anything was delivered.
+func (l *Limited[T]) fire(gen uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if gen != l.gen {
+		return
+	}
+
+	l.timer = nil
+	if !l.pending {
+		return
+	}
+	l.flush()
+	if !l.debounce {
+		l.schedule()
+	}
+}
+
+// flush delivers pending change to wrapped Observer.
+// Caller must hold mu.
+func (l *Limited[T]) flush() {
+	if !l.pending {
+		return
+	}
+
+	l.pending = false
+	l.observer.Update(l.state, l.prevState)
+}
@@ -0,0 +1,127 @@
+package observer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+	"github.com/kyosheek/go-patterns/pkg/observer/observertest"
+)
+
+type transition = observertest.Transition[int]
+
+func TestThrottle(t *testing.T) {
+	t.Parallel()
+
+	clock := observertest.NewClock(time.Unix(0, 0))
+	r := observertest.NewRecorder[int]()
+	s := observer.NewSubject[int]()
+	s.Attach(observer.Throttle[int](r, time.Second, observer.WithLimitClock(clock)))
+
+	for i := 1; i <= 5; i++ {
+		s.SetState(i)
+	}
+	observertest.ExpectTransitions(t, r, transition{State: 1, PrevState: 0})
+
+	clock.Advance(time.Second)
+	observertest.ExpectTransitions(t, r, transition{State: 1, PrevState: 0}, transition{State: 5, PrevState: 1})
+
+	// next change during the interval started by trailing delivery waits for it
+	s.SetState(6)
+	observertest.ExpectSequence(t, r, 1, 5)
+	clock.Advance(time.Second)
+	observertest.ExpectTransitions(t, r, transition{State: 1, PrevState: 0}, transition{State: 5, PrevState: 1}, transition{State: 6, PrevState: 5})
+
+	// quiet interval closes the window, next change is delivered right away
+	clock.Advance(time.Second)
+	s.SetState(7)
+	observertest.ExpectSequence(t, r, 1, 5, 6, 7)
+	if clock.Pending() != 1 {
+		t.Fatalf("clock has %d pending calls, want 1", clock.Pending())
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	t.Parallel()
+
+	clock := observertest.NewClock(time.Unix(0, 0))
+	r := observertest.NewRecorder[int]()
+	s := observer.NewSubject[int]()
+	s.Attach(observer.Debounce[int](r, time.Second, observer.WithLimitClock(clock)))
+
+	for i := 1; i <= 3; i++ {
+		s.SetState(i)
+		clock.Advance(time.Second / 2)
+	}
+	observertest.ExpectSequence[int](t, r)
+
+	clock.Advance(time.Second / 2)
+	observertest.ExpectTransitions(t, r, transition{State: 3, PrevState: 0})
+
+	s.SetState(4)
+	clock.Advance(time.Second)
+	observertest.ExpectTransitions(t, r, transition{State: 3, PrevState: 0}, transition{State: 4, PrevState: 3})
+	if clock.Pending() != 0 {
+		t.Fatalf("clock has %d pending calls, want 0", clock.Pending())
+	}
+}
+
+func TestLimitedFlush(t *testing.T) {
+	t.Parallel()
+
+	clock := observertest.NewClock(time.Unix(0, 0))
+	r := observertest.NewRecorder[int]()
+	s := observer.NewSubject[int]()
+	d := observer.Debounce[int](r, time.Second, observer.WithLimitClock(clock))
+	s.Attach(d)
+
+	s.SetState(1)
+	s.SetState(2)
+	s.Close()
+	d.Flush()
+	observertest.ExpectTransitions(t, r, transition{State: 2, PrevState: 0})
+
+	clock.Advance(time.Second)
+	observertest.ExpectSequence(t, r, 2)
+}
+
+func TestThrottleSystemClock(t *testing.T) {
+	t.Parallel()
+
+	r := observertest.NewRecorder[int]()
+	s := observer.NewSubject[int]()
+	s.Attach(observer.Throttle[int](r, 5*time.Millisecond))
+
+	for i := 1; i <= 100; i++ {
+		s.SetState(i)
+	}
+
+	observertest.WaitFor(t, func() bool {
+		states := r.States()
+		return len(states) > 0 && states[len(states)-1] == 100
+	}, time.Second,
+	)
+	if r.Len() != 2 {
+		t.Fatalf("throttled observer was notified %d times, want 2", r.Len())
+	}
+}
+
+// negative tests
+
+func TestLimitInvalidArgumentsShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	check := func(name string, f func()) {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("%s did not panic", name)
+			}
+		}()
+		f()
+	}
+
+	r := observertest.NewRecorder[int]()
+	check("Throttle(nil)", func() { observer.Throttle[int](nil, time.Second) })
+	check("Debounce(0)", func() { observer.Debounce[int](r, 0) })
+	check("WithLimitClock(nil)", func() { observer.WithLimitClock(nil) })
+}
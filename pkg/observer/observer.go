@@ -1,56 +1,585 @@
 package observer
 
+import (
+	"context"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 // Observer interface requires Update function that will run
 // on concrete instance when Subject receives new state.
 type Observer[T any] interface {
 	Update(state T, prevState T)
 }
 
+// ObserverFunc is an adapter to use ordinary function as Observer.
+// Functions are not comparable, so Detach ignores function adapters,
+// they can only be removed through the returned Subscription.
+type ObserverFunc[T any] func(state T, prevState T)
+
+// Update calls f(state, prevState).
+func (f ObserverFunc[T]) Update(state T, prevState T) {
+	f(state, prevState)
+}
+
+// Event is a single state change of Subject.
+// Version is increased by one on every state change of a Subject,
+// so a gap between versions means Observer missed some changes.
+// Time is the moment state was changed.
+// Replay is set for changes made before Observer was attached,
+// see WithBehavior and WithReplay.
+type Event[T any] struct {
+	State     T
+	PrevState T
+	Version   uint64
+	Time      time.Time
+	Replay    bool
+}
+
+// EventObserver is an Observer that receives the whole Event.
+// Subject calls OnEvent instead of Update on such Observer instances.
+type EventObserver[T any] interface {
+	Observer[T]
+	OnEvent(e Event[T])
+}
+
+// EventFunc is an adapter to use ordinary function as EventObserver.
+// Like ObserverFunc, it can only be removed through the returned Subscription.
+type EventFunc[T any] func(e Event[T])
+
+// Update calls f with Event that has only states set.
+func (f EventFunc[T]) Update(state T, prevState T) {
+	f(Event[T]{State: state, PrevState: prevState})
+}
+
+// OnEvent calls f(e).
+func (f EventFunc[T]) OnEvent(e Event[T]) {
+	f(e)
+}
+
+// Subscription is a handle returned by Attach.
+// Unsubscribe detaches observers attached by that call,
+// it is safe to call it more than once.
+type Subscription interface {
+	Unsubscribe()
+}
+
+// subscriber holds a single attached Observer.
+// detached is set once Observer is removed from Subject,
+// so in-flight notifications can skip it.
+type subscriber[T any] struct {
+	observer Observer[T]
+	queue    *queue[T] // set only for asynchronous Subject
+	priority int
+	filter   func(state, prevState T) bool // nil if every change is delivered
+	since    uint64                        // version of the last replayed change
+	pending  []Event[T]                    // replayed changes not yet delivered
+	ready    chan struct{}                 // closed once pending changes are delivered, nil if none
+	owner    context.Context               // Observer is detached once owner is done, nil if never
+	gone     chan struct{}                 // closed on detach, nil unless owner is set
+	current  bool                          // current state is delivered on attach
+	noReplay bool                          // nothing is delivered on attach
+	detached uint32
+	panics   uint32
+}
+
+// call passes e to subscriber Observer and returns error of FallibleObserver.
+func (sub *subscriber[T]) call(ctx context.Context, e Event[T]) error {
+	switch o := sub.observer.(type) {
+	case *fallible[T]:
+		return o.observer.Update(e.State, e.PrevState)
+	case ContextObserver[T]:
+		o.UpdateContext(ctx, e.State, e.PrevState)
+	case EventObserver[T]:
+		o.OnEvent(e)
+	default:
+		sub.observer.Update(e.State, e.PrevState)
+	}
+	return nil
+}
+
+// accepts reports whether e passes subscriber filter.
+func (sub *subscriber[T]) accepts(e Event[T]) bool {
+	return sub.filter == nil || sub.filter(e.State, e.PrevState)
+}
+
+// replayedAlready reports whether e was replayed to subscriber when it was attached.
+func (sub *subscriber[T]) replayedAlready(e Event[T]) bool {
+	return sub.since > 0 && e.Version <= sub.since
+}
+
+// source returns Observer or FallibleObserver instance that was attached.
+func (sub *subscriber[T]) source() any {
+	if o, ok := sub.observer.(*fallible[T]); ok {
+		return o.observer
+	}
+	return sub.observer
+}
+
+// isDetached reports whether subscriber was removed from its Subject.
+func (sub *subscriber[T]) isDetached() bool {
+	return atomic.LoadUint32(&sub.detached) == 1
+}
+
+// subscription implements Subscription for observers attached by a single Attach call.
+type subscription[T any] struct {
+	subject     *Subject[T]
+	subscribers []*subscriber[T]
+}
+
+// Unsubscribe detaches observers attached by the Attach call
+// that returned current subscription.
+func (s *subscription[T]) Unsubscribe() {
+	s.subject.remove(func(sub *subscriber[T]) bool {
+		for _, own := range s.subscribers {
+			if sub == own {
+				return true
+			}
+		}
+		return false
+	},
+	)
+}
+
 // Subject is a struct that holds multiple Observer instances
 // that need to react on change of given struct state.
+//
+// Subject is safe for concurrent use. State changes are applied one at a time
+// and every Observer receives them in the same order they were applied,
+// each notification completes before the next state change starts.
+// Update may call Attach, Detach and State of its own Subject,
+// but must not change its state, that would deadlock.
 type Subject[T any] struct {
-	observers []Observer[T]
+	notifyMu  sync.Mutex   // serializes state changes together with their notifications
+	mu        sync.RWMutex // protects observers, state, version, changes, history and closed
+	observers []*subscriber[T]
 	state     T
+	version   uint64    // number of state changes
+	changed   time.Time // time of the last state change
+	clock     Clock     // stamps state changes, nil for SystemClock
+	closed    bool
+	onClose   []func()
+
+	equal func(a, b T) bool // nil unless changes to equal state are skipped
+
+	behavior   bool       // replay current state to new Observer instances
+	replaySize int        // number of changes replayed to new Observer instances
+	changes    []Event[T] // last changes to replay
+
+	historySize int // number of states kept for Undo, 0 if history is off
+	undo        []T
+	redo        []T
+
+	store Store[T] // saves every state change, nil if persistence is off
+
+	instrument Instrumentation // receives metrics, nil if instrumentation is off
+
+	recover    bool // recover panics of Observer instances
+	onError    ErrorHandler
+	panicLimit uint32 // number of panics after which Observer is detached, 0 for no limit
+
+	queueSize int // queue size of every Observer, 0 for synchronous Subject
+	policy    OverflowPolicy
+	workers   sync.WaitGroup
 }
 
-// Attach adds new Observer instances to current Subject.
+// Option configures Subject created by NewSubject.
+type Option[T any] func(*Subject[T])
+
+// Attach adds new Observer instances to current Subject and returns
+// Subscription that detaches exactly these instances.
 // Subject must be initialized before Attach calls.
-func (s *Subject[T]) Attach(observers ...Observer[T]) {
+func (s *Subject[T]) Attach(observers ...Observer[T]) Subscription {
 	if s == nil {
 		panic("subject is not initialized")
 	}
 
-	s.observers = append(s.observers, observers...)
+	sub, _ := s.attach(observers)
+	sub.replay()
+	return sub
+}
+
+// AttachOption configures a single Observer attached by AttachWith.
+type AttachOption[T any] func(*subscriber[T])
+
+// AttachWith adds new Observer instance configured by given options to current Subject
+// and returns Subscription that detaches it.
+// Subject must be initialized before AttachWith calls.
+func (s *Subject[T]) AttachWith(observer Observer[T], opts ...AttachOption[T]) Subscription {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	sub, _ := s.attach([]Observer[T]{observer}, opts...)
+	sub.replay()
+	return sub
+}
+
+// attach adds new Observer instances configured by opts to current Subject.
+// attach reports false and adds nothing if Subject is closed.
+// Caller must call replay on returned subscription,
+// asynchronous Subject queues replayed changes right away.
+func (s *Subject[T]) attach(observers []Observer[T], opts ...AttachOption[T]) (*subscription[T], bool) {
+	subscribers := make([]*subscriber[T], 0, len(observers))
+	for _, observer := range observers {
+		sub := &subscriber[T]{observer: observer}
+		for _, opt := range opts {
+			opt(sub)
+		}
+		subscribers = append(subscribers, sub)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return &subscription[T]{subject: s}, false
+	}
+	replayed := s.replayed()
+	for _, sub := range subscribers {
+		if sub.owner != nil {
+			sub.gone = make(chan struct{})
+			go s.watch(sub)
+		}
+		if !sub.noReplay && (s.replaySize > 0 || s.behavior || sub.current) {
+			sub.since = s.version
+			events := replayed
+			if sub.current {
+				events = []Event[T]{s.current()}
+			}
+			for _, e := range events {
+				if sub.accepts(e) {
+					sub.pending = append(sub.pending, e)
+				}
+			}
+		}
+		if s.queueSize > 0 {
+			sub.queue = newQueue[T](s.queueSize, s.policy)
+			sub.queue.replay(sub.pending)
+			sub.pending = nil
+			s.workers.Add(1)
+			go s.run(sub)
+		}
+		if len(sub.pending) > 0 {
+			sub.ready = make(chan struct{})
+		}
+	}
+	s.observers = insert(s.observers, subscribers)
+	s.mu.Unlock()
+
+	if s.instrument != nil && len(subscribers) > 0 {
+		s.instrument.Attached(len(subscribers))
+	}
+
+	return &subscription[T]{
+		subject:     s,
+		subscribers: subscribers,
+	}, true
+}
+
+// Detach removes every attachment of given Observer instances from current Subject.
+// Detach may be called from inside Update, detached Observer instances
+// will not receive the rest of current notification.
+// Observer instances of non-comparable types, such as function adapters
+// ObserverFunc, EventFunc and ContextFunc, are never matched and stay attached,
+// they can only be removed through the returned Subscription.
+// Subject must be initialized before Detach calls.
+func (s *Subject[T]) Detach(observers ...Observer[T]) {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	s.remove(func(sub *subscriber[T]) bool {
+		for _, observer := range observers {
+			if sameObserver(sub.observer, observer) {
+				return true
+			}
+		}
+		return false
+	},
+	)
+}
+
+// insert returns new slice of observers with subscribers placed after
+// every observer of the same or higher priority.
+// observers slice is not modified, so notifyAll can keep iterating over it.
+func insert[T any](observers, subscribers []*subscriber[T]) []*subscriber[T] {
+	merged := make([]*subscriber[T], 0, len(observers)+len(subscribers))
+	merged = append(merged, observers...)
+	for _, sub := range subscribers {
+		i := len(merged)
+		for i > 0 && merged[i-1].priority < sub.priority {
+			i--
+		}
+		merged = append(merged, nil)
+		copy(merged[i+1:], merged[i:])
+		merged[i] = sub
+	}
+	return merged
+}
+
+// remove detaches subscribers matched by given function
+// and drops notifications still queued for them.
+func (s *Subject[T]) remove(match func(sub *subscriber[T]) bool) {
+	for _, sub := range s.detach(match) {
+		s.stop(sub)
+	}
+}
+
+// stop drops notifications still queued for detached subscriber.
+func (s *Subject[T]) stop(sub *subscriber[T]) {
+	if sub.queue == nil {
+		return
+	}
+	if dropped := sub.queue.cancel(); dropped > 0 && s.instrument != nil {
+		s.instrument.Dropped(sub.source(), dropped)
+	}
+}
+
+// detach removes subscribers matched by given function and returns them.
+// observers slice is rebuilt instead of being filtered in place,
+// so notifyAll can keep iterating over the old one.
+func (s *Subject[T]) detach(match func(sub *subscriber[T]) bool) []*subscriber[T] {
+	s.mu.Lock()
+	var detached []*subscriber[T]
+	observers := make([]*subscriber[T], 0, len(s.observers))
+	for _, sub := range s.observers {
+		if match(sub) {
+			atomic.StoreUint32(&sub.detached, 1)
+			if sub.gone != nil {
+				close(sub.gone)
+			}
+			detached = append(detached, sub)
+			continue
+		}
+		observers = append(observers, sub)
+	}
+	s.observers = observers
+	s.mu.Unlock()
+
+	if s.instrument != nil && len(detached) > 0 {
+		s.instrument.Detached(len(detached))
+	}
+
+	return detached
+}
+
+// State returns current Subject state.
+// Subject must be initialized before State calls.
+func (s *Subject[T]) State() T {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state
 }
 
 // SetState updates current Subject state and notifies all attached Observer instances.
+// Subject created with WithEqual or WithDistinct ignores state equal to current one.
 // Subject must be initialized before Attach calls.
 func (s *Subject[T]) SetState(state T) {
 	if s == nil {
 		panic("subject is not initialized")
 	}
 
-	prevState := s.state
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.reportAll(s.set(context.Background(), state, changeSet))
+}
+
+// Update atomically replaces current Subject state with the result of fn
+// and notifies all attached Observer instances once.
+// fn must not change state of current Subject, that would deadlock.
+// Subject must be initialized before Update calls.
+func (s *Subject[T]) Update(fn func(cur T) T) {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.reportAll(s.set(context.Background(), fn(s.State()), changeSet))
+}
+
+// CompareAndSwap sets state of s to new and notifies Observer instances
+// only if current state equals old. CompareAndSwap reports whether state was swapped.
+// Subject must be initialized before CompareAndSwap calls.
+func CompareAndSwap[T comparable](s *Subject[T], old, new T) bool {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	if s.State() != old {
+		return false
+	}
+	s.reportAll(s.set(context.Background(), new, changeSet))
+	return true
+}
+
+// change describes how set treats new state.
+type change int
+
+const (
+	changeSet     change = iota // regular change, skipped if state is equal to current one
+	changeForce                 // regular change, never skipped
+	changeHistory               // undo or redo, never skipped and does not touch history
+)
+
+// set updates current Subject state, notifies all attached Observer instances
+// and returns their failures.
+// Caller must hold notifyMu.
+func (s *Subject[T]) set(ctx context.Context, state T, kind change) *NotifyError[T] {
+	prevState := s.State()
+	if kind == changeSet && s.equal != nil && s.equal(prevState, state) {
+		return nil
+	}
+
+	s.mu.Lock()
 	s.state = state
-	s.notifyAll(prevState)
+	s.version++
+	s.changed = s.now()
+	s.record(prevState)
+	if kind != changeHistory {
+		s.remember(prevState)
+	}
+	s.mu.Unlock()
+
+	s.persist(state)
+
+	return s.notifyAll(ctx, prevState)
 }
 
 // notifyAll is a helper function that calls Update on attached Observer instances.
 // Since notifyAll called only inside SetState calls, this function does not
 // directly check for instantiation of current Subject.
-func (s *Subject[T]) notifyAll(prevState T) {
-	for _, observer := range s.observers {
-		observer.Update(s.state, prevState)
+// Observers are read once, so Attach and Detach calls made by Observer instances
+// do not affect the order of current notification.
+// Once ctx is done remaining Observer instances are skipped.
+// Failures of synchronously notified Observer instances and skipped ones are returned.
+func (s *Subject[T]) notifyAll(ctx context.Context, prevState T) *NotifyError[T] {
+	s.mu.RLock()
+	e := Event[T]{
+		State:     s.state,
+		PrevState: prevState,
+		Version:   s.version,
+		Time:      s.changed,
+	}
+	observers := s.observers
+	s.mu.RUnlock()
+
+	var (
+		errs    []*ObserverError[T]
+		skipped []any
+	)
+	if s.instrument != nil {
+		defer func(start time.Time) {
+			s.instrument.Fired(time.Since(start))
+		}(time.Now())
+	}
+	for _, sub := range observers {
+		if sub.isDetached() || sub.replayedAlready(e) || !sub.accepts(e) {
+			continue
+		}
+		if ctx.Err() != nil {
+			skipped = append(skipped, sub.source())
+			continue
+		}
+		if err := s.notify(ctx, sub, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 && len(skipped) == 0 {
+		return nil
+	}
+	notifyErr := &NotifyError[T]{
+		Errors:  errs,
+		Skipped: skipped,
 	}
+	if len(skipped) > 0 {
+		notifyErr.Err = ctx.Err()
+	}
+	return notifyErr
+}
+
+// notify delivers e to subscriber Observer and returns its failure,
+// or queues it when Subject is asynchronous.
+func (s *Subject[T]) notify(ctx context.Context, sub *subscriber[T], e Event[T]) *ObserverError[T] {
+	if sub.queue != nil {
+		if dropped := sub.queue.push(delivery[T]{ctx: ctx, event: e}); dropped > 0 && s.instrument != nil {
+			s.instrument.Dropped(sub.source(), dropped)
+		}
+		return nil
+	}
+	if sub.ready != nil {
+		<-sub.ready
+	}
+	return s.deliver(ctx, sub, e)
+}
+
+// deliver calls subscriber Observer and returns its failure.
+// Panic of Observer is recovered only if Subject is configured to.
+func (s *Subject[T]) deliver(ctx context.Context, sub *subscriber[T], e Event[T]) (err *ObserverError[T]) {
+	if s.instrument != nil {
+		defer func(start time.Time) {
+			s.instrument.Notified(sub.source(), time.Since(start))
+		}(time.Now())
+	}
+	if s.recover {
+		defer func() {
+			if r := recover(); r != nil {
+				err = s.panicked(sub, e, &PanicError{Value: r, Stack: debug.Stack()})
+			}
+		}()
+	}
+
+	if callErr := sub.call(ctx, e); callErr != nil {
+		return &ObserverError[T]{
+			Observer: sub.source(),
+			Event:    e,
+			Err:      callErr,
+		}
+	}
+	return nil
+}
+
+// sameObserver reports whether a and b are the same Observer instance.
+// Observer instances of non-comparable types (e.g. functions) never match,
+// comparing them with == would panic.
+func sameObserver[T any](a, b Observer[T]) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb || !ta.Comparable() {
+		return false
+	}
+	return a == b
 }
 
 // NewSubject creates new Subject with given state type.
 // Subject can attach Observer instances
 // and set new State.
-func NewSubject[T any]() *Subject[T] {
-	var s T
-	return &Subject[T]{
-		observers: make([]Observer[T], 0),
-		state:     s,
+// Options, if any, configure Subject behaviour.
+func NewSubject[T any](opts ...Option[T]) *Subject[T] {
+	var state T
+	s := &Subject[T]{
+		observers: make([]*subscriber[T], 0),
+		state:     state,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.restore()
+	return s
 }
@@ -1,8 +1,10 @@
 package observer
 
 import (
+	"context"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -40,7 +42,7 @@ func TestNew(t *testing.T) {
 	got := NewSubject[int]()
 
 	want := &Subject[int]{
-		observers: make([]Observer[int], 0),
+		observers: make([]*subscriber[int], 0),
 		state:     0,
 	}
 
@@ -59,11 +61,99 @@ func TestAttach(t *testing.T) {
 	s.Attach(o1, o2)
 
 	expected := []Observer[int]{o1, o2}
-	if !reflect.DeepEqual(s.observers, expected) {
-		t.Fatalf("Attach() resulted in observers %#v, want %#v", s.observers, expected)
+	if got := attached(s); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Attach() resulted in observers %#v, want %#v", got, expected)
 	}
 }
 
+func TestDetach(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o1 := &testObserver{}
+	o2 := &testObserver{}
+	s.Attach(o1, o2, o1)
+
+	s.Detach(o1)
+
+	expected := []Observer[int]{o2}
+	if got := attached(s); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Detach() resulted in observers %#v, want %#v", got, expected)
+	}
+
+	s.SetState(1)
+	if len(o1.states) != 0 {
+		t.Fatalf("detached observer received %v", o1.states)
+	}
+	if len(o2.states) != 1 {
+		t.Fatalf("attached observer should have 1 update, got %d", len(o2.states))
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o1 := &testObserver{}
+	o2 := &testObserver{}
+	s.Attach(o1)
+	sub := s.Attach(o1, o2)
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+
+	expected := []Observer[int]{o1}
+	if got := attached(s); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Unsubscribe() resulted in observers %#v, want %#v", got, expected)
+	}
+}
+
+func TestDetachInsideUpdate(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	before := &testObserver{}
+	after := &testObserver{}
+
+	var calls int
+	var sub Subscription
+	self := ObserverFunc[int](func(_, _ int) {
+		calls++
+		sub.Unsubscribe()
+		s.Detach(after)
+	},
+	)
+
+	s.Attach(before)
+	sub = s.Attach(self)
+	s.Attach(after)
+
+	s.SetState(1)
+	s.SetState(2)
+
+	if calls != 1 {
+		t.Fatalf("self-detaching observer called %d times, want 1", calls)
+	}
+	if len(before.states) != 2 {
+		t.Fatalf("observer before detached one should have 2 updates, got %d", len(before.states))
+	}
+	if len(after.states) != 0 {
+		t.Fatalf("observer detached during notification received %v", after.states)
+	}
+}
+
+// attached returns Observer instances currently attached to s.
+func attached(s *Subject[int]) []Observer[int] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	observers := make([]Observer[int], 0, len(s.observers))
+	for _, sub := range s.observers {
+		observers = append(observers, sub.observer)
+	}
+	return observers
+}
+
 func TestSetState(t *testing.T) {
 	t.Parallel()
 
@@ -98,7 +188,7 @@ func TestNotifyAll(t *testing.T) {
 
 	const state = 7
 	s.state = state
-	s.notifyAll(state)
+	s.notifyAll(context.Background(), state)
 
 	for i, o := range []*testObserver{o1, o2} {
 		if got, ok := o.lastState(t); !ok || got != state {
@@ -141,6 +231,319 @@ func TestThreadSafety(t *testing.T) {
 	wg.Wait()
 }
 
+func TestState(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	if got := s.State(); got != 0 {
+		t.Fatalf("State() of new subject = %d, want 0", got)
+	}
+
+	s.SetState(5)
+	if got := s.State(); got != 5 {
+		t.Fatalf("State() after SetState(5) = %d, want 5", got)
+	}
+}
+
+// transitionObserver is a helper Observer implementation that stores
+// every (state, prevState) pair it receives.
+type transitionObserver struct {
+	mu          sync.Mutex
+	transitions [][2]int
+}
+
+func (o *transitionObserver) Update(state, prevState int) {
+	o.mu.Lock()
+	o.transitions = append(o.transitions, [2]int{state, prevState})
+	o.mu.Unlock()
+}
+
+func TestConcurrentSetStateOrdering(t *testing.T) {
+	t.Parallel()
+
+	const (
+		writers         = 16
+		updatesPerWrite = 64
+		observers       = 4
+	)
+
+	s := NewSubject[int]()
+	recorders := make([]*transitionObserver, observers)
+	for i := range recorders {
+		recorders[i] = &transitionObserver{}
+		s.Attach(recorders[i])
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < updatesPerWrite; j++ {
+				s.SetState(i*updatesPerWrite + j + 1)
+				_ = s.State()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	want := recorders[0].transitions
+	if len(want) != writers*updatesPerWrite {
+		t.Fatalf("observer received %d updates, want %d", len(want), writers*updatesPerWrite)
+	}
+
+	// every transition must start where previous one ended
+	prev := 0
+	for i, tr := range want {
+		if tr[1] != prev {
+			t.Fatalf("transition %d has prevState %d, want %d", i, tr[1], prev)
+		}
+		prev = tr[0]
+	}
+	if got := s.State(); got != prev {
+		t.Fatalf("State() = %d, want last notified state %d", got, prev)
+	}
+
+	for i, o := range recorders[1:] {
+		if !reflect.DeepEqual(o.transitions, want) {
+			t.Fatalf("observer %d received transitions in different order", i+2)
+		}
+	}
+}
+
+func TestConcurrentAttachDetach(t *testing.T) {
+	t.Parallel()
+
+	const goroutines = 16
+
+	s := NewSubject[int]()
+	stable := &testObserver{}
+	s.Attach(stable)
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(3)
+
+		go func() {
+			defer wg.Done()
+
+			o := &testObserver{}
+			sub := s.Attach(o)
+			sub.Unsubscribe()
+		}()
+		go func() {
+			defer wg.Done()
+
+			o := &testObserver{}
+			s.Attach(o)
+			s.Detach(o)
+		}()
+		go func(i int) {
+			defer wg.Done()
+
+			s.SetState(i)
+		}(i)
+	}
+	wg.Wait()
+
+	if got := attached(s); !reflect.DeepEqual(got, []Observer[int]{stable}) {
+		t.Fatalf("observers after concurrent attach/detach = %#v, want only stable one", got)
+	}
+	if len(stable.states) != goroutines {
+		t.Fatalf("stable observer received %d updates, want %d", len(stable.states), goroutines)
+	}
+}
+
+func TestStateInsideUpdate(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	var seen int
+	s.Attach(ObserverFunc[int](func(state, _ int) {
+		seen = s.State()
+		s.Attach(&testObserver{})
+	},
+	),
+	)
+
+	s.SetState(3)
+	if seen != 3 {
+		t.Fatalf("State() inside Update = %d, want 3", seen)
+	}
+}
+
+func TestObserverFunc(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	var got [][2]int
+	s.Attach(ObserverFunc[int](func(state, prevState int) {
+		got = append(got, [2]int{state, prevState})
+	},
+	),
+	)
+
+	s.SetState(1)
+	s.SetState(2)
+
+	if want := [][2]int{{1, 0}, {2, 1}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ObserverFunc received %v, want %v", got, want)
+	}
+}
+
+func TestEventFunc(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	var events []Event[int]
+	s.Attach(EventFunc[int](func(e Event[int]) {
+		events = append(events, e)
+	},
+	),
+	)
+
+	s.SetState(10)
+	s.SetState(20)
+
+	if len(events) != 2 {
+		t.Fatalf("EventFunc received %d events, want 2", len(events))
+	}
+	for i, e := range events {
+		if e.Version != uint64(i+1) {
+			t.Fatalf("event %d has version %d, want %d", i, e.Version, i+1)
+		}
+		if e.Time.IsZero() {
+			t.Fatalf("event %d has no time", i)
+		}
+	}
+	if events[1].State != 20 || events[1].PrevState != 10 {
+		t.Fatalf("second event = %+v, want 20 after 10", events[1])
+	}
+	if events[1].Time.Before(events[0].Time) {
+		t.Fatalf("event times are not ordered: %v before %v", events[1].Time, events[0].Time)
+	}
+}
+
+func TestEventVersionGap(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithAsync[int](1, DropOldest))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var versions []uint64
+	s.Attach(EventFunc[int](func(e Event[int]) {
+		if e.Version == 1 {
+			close(started)
+			<-release
+		}
+		versions = append(versions, e.Version)
+	},
+	),
+	)
+
+	s.SetState(1)
+	<-started
+	s.SetState(2)
+	s.SetState(3)
+	close(release)
+	s.Close()
+
+	// the second notification was dropped, so the gap is visible
+	if want := []uint64{1, 3}; !reflect.DeepEqual(versions, want) {
+		t.Fatalf("received versions %v, want %v", versions, want)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	const (
+		goroutines    = 16
+		incrementsPer = 64
+	)
+
+	s := NewSubject[int]()
+	o := &transitionObserver{}
+	s.Attach(o)
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < incrementsPer; j++ {
+				s.Update(func(cur int) int { return cur + 1 })
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := s.State(); got != goroutines*incrementsPer {
+		t.Fatalf("State() after concurrent Update = %d, want %d", got, goroutines*incrementsPer)
+	}
+	for i, tr := range o.transitions {
+		if want := [2]int{i + 1, i}; tr != want {
+			t.Fatalf("transition %d = %v, want %v", i, tr, want)
+		}
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[string]()
+	var calls int
+	s.Attach(ObserverFunc[string](func(_, _ string) { calls++ }))
+
+	if !CompareAndSwap(s, "", "a") {
+		t.Fatalf("CompareAndSwap(\"\", \"a\") = false, want true")
+	}
+	if CompareAndSwap(s, "", "b") {
+		t.Fatalf("CompareAndSwap(\"\", \"b\") = true, want false")
+	}
+	if got := s.State(); got != "a" {
+		t.Fatalf("State() = %q, want %q", got, "a")
+	}
+	if calls != 1 {
+		t.Fatalf("observer called %d times, want 1", calls)
+	}
+}
+
+func TestCompareAndSwapConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const goroutines = 32
+
+	s := NewSubject[int]()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		winners int
+	)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			if CompareAndSwap(s, 0, i+1) {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if winners != 1 {
+		t.Fatalf("%d goroutines swapped state, want 1", winners)
+	}
+}
+
 // negative tests
 
 func TestAttachNoObservers(t *testing.T) {
@@ -159,6 +562,56 @@ func TestAttachNoObservers(t *testing.T) {
 	}
 }
 
+func TestDetachFuncObserver(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	f := ObserverFunc[int](func(_, _ int) {})
+	s.Attach(f)
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("Detach with non-comparable observer panicked: %v", r)
+		}
+	}()
+	s.Detach(f)
+
+	if len(s.observers) != 1 {
+		t.Fatalf("non-comparable observer must stay attached, got %d observers", len(s.observers))
+	}
+}
+
+func TestDetachFuncAdaptersUseSubscription(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	var calls int32
+	adapters := []Observer[int]{
+		ObserverFunc[int](func(_, _ int) { atomic.AddInt32(&calls, 1) }),
+		EventFunc[int](func(Event[int]) { atomic.AddInt32(&calls, 1) }),
+		ContextFunc[int](func(context.Context, int, int) { atomic.AddInt32(&calls, 1) }),
+	}
+	subs := make([]Subscription, 0, len(adapters))
+	for _, f := range adapters {
+		subs = append(subs, s.Attach(f))
+	}
+
+	// Detach does not match function adapters, they keep receiving updates
+	s.Detach(adapters...)
+	s.SetState(1)
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Fatalf("adapters were called %d times after Detach, want 3", got)
+	}
+
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	s.SetState(2)
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Fatalf("adapters were called %d times after Unsubscribe, want 3", got)
+	}
+}
+
 func TestSetStateNoObservers(t *testing.T) {
 	t.Parallel()
 
@@ -206,6 +659,22 @@ func TestNilSubjectMethodsShouldPanic(t *testing.T) {
 	}
 
 	check("Attach", func() { s.Attach() })
+	check("Detach", func() { s.Detach() })
+	check("AttachWith", func() { s.AttachWith(&testObserver{}) })
+	check("AttachWeak", func() { s.AttachWeak() })
+	check("State", func() { s.State() })
+	check("ForceSet", func() { s.ForceSet(1) })
+	check("AttachFallible", func() { s.AttachFallible() })
+	check("SetStateErr", func() { _ = s.SetStateErr(1) })
+	check("Begin", func() { s.Begin() })
+	check("Undo", func() { s.Undo() })
+	check("Redo", func() { s.Redo() })
+	check("History", func() { s.History() })
+	check("SubscribeWith", func() { s.SubscribeWith(context.Background(), 0) })
+	check("SetStateContext", func() { _ = s.SetStateContext(context.Background(), 1) })
+	check("Update", func() { s.Update(func(cur int) int { return cur }) })
+	check("CompareAndSwap", func() { CompareAndSwap(s, 0, 1) })
+	check("Close", func() { s.Close() })
 	check("SetState", func() { s.SetState(1) })
-	check("notifyAll", func() { s.notifyAll(0) })
+	check("notifyAll", func() { s.notifyAll(context.Background(), 0) })
 }
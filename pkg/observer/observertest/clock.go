@@ -0,0 +1,144 @@
+package observertest
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+)
+
+// Clock is a fake observer.Clock that moves only when told to,
+// by Advance or while WaitFor waits for asynchronous delivery.
+// Calls scheduled with AfterFunc happen during Advance,
+// in the order of their due time, on the goroutine that calls Advance.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*timer
+}
+
+// NewClock creates Clock that tells given time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now implements observer.Clock interface.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// AfterFunc implements observer.Clock interface.
+func (c *Clock) AfterFunc(d time.Duration, f func()) observer.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{clock: c, due: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d and makes every call that is due by then.
+// Calls scheduled by those calls are made too, if they are due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	until := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].due.Before(c.timers[j].due) })
+		if len(c.timers) == 0 || c.timers[0].due.After(until) {
+			c.now = until
+			c.mu.Unlock()
+			return
+		}
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		if t.due.After(c.now) {
+			c.now = t.due
+		}
+		c.mu.Unlock()
+
+		t.f()
+	}
+}
+
+// WaitFor waits until predicate returns true, advancing the clock meanwhile,
+// and fails t if it does not happen within timeout of fake time.
+// Between checks of predicate it lets other goroutines, such as workers
+// of asynchronous Subject, run for PollInterval of real time and then advances
+// the clock to the next call scheduled by AfterFunc, or by PollInterval if there is none.
+func (c *Clock) WaitFor(t testing.TB, predicate func() bool, timeout time.Duration) {
+	t.Helper()
+
+	deadline := c.Now().Add(timeout)
+	for !predicate() {
+		now := c.Now()
+		if !now.Before(deadline) {
+			t.Fatalf("condition not satisfied within %v of fake time", timeout)
+			return
+		}
+		time.Sleep(PollInterval)
+
+		step := PollInterval
+		if next, ok := c.next(); ok && next.After(now) {
+			step = next.Sub(now)
+		}
+		if now.Add(step).After(deadline) {
+			step = deadline.Sub(now)
+		}
+		c.Advance(step)
+	}
+}
+
+// next returns due time of the earliest scheduled call.
+func (c *Clock) next() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.timers) == 0 {
+		return time.Time{}, false
+	}
+	next := c.timers[0].due
+	for _, t := range c.timers[1:] {
+		if t.due.Before(next) {
+			next = t.due
+		}
+	}
+	return next, true
+}
+
+// Pending returns number of scheduled calls that are not made yet.
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// timer is a call scheduled by Clock.AfterFunc.
+type timer struct {
+	clock *Clock
+	due   time.Time
+	f     func()
+}
+
+// Stop implements observer.Timer interface.
+func (t *timer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package observertest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+)
+
+func TestClockAdvance(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(0, 0)
+	clock := NewClock(start)
+
+	var calls []time.Duration
+	record := func() { calls = append(calls, clock.Now().Sub(start)) }
+	clock.AfterFunc(3*time.Second, record)
+	clock.AfterFunc(time.Second, func() {
+		record()
+		clock.AfterFunc(time.Second, record)
+	},
+	)
+	stopped := clock.AfterFunc(2*time.Second, record)
+	if !stopped.Stop() || stopped.Stop() {
+		t.Fatalf("Stop() of pending call must report true once")
+	}
+
+	clock.Advance(2 * time.Second)
+	if want := []time.Duration{time.Second, 2 * time.Second}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls made at %v, want %v", calls, want)
+	}
+	if clock.Pending() != 1 {
+		t.Fatalf("Pending() = %d, want 1", clock.Pending())
+	}
+
+	clock.Advance(2 * time.Second)
+	if want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls made at %v, want %v", calls, want)
+	}
+	if got := clock.Now().Sub(start); got != 4*time.Second {
+		t.Fatalf("clock advanced by %v, want 4s", got)
+	}
+}
+
+func TestClockWaitForAsync(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(0, 0)
+	clock := NewClock(start)
+	s := observer.NewSubject[int](observer.WithAsync[int](4, observer.Block), observer.WithClock[int](clock))
+	defer s.Close()
+	r := NewRecorder[int]()
+	s.Attach(observer.Debounce[int](r, time.Minute, observer.WithLimitClock(clock)))
+
+	for i := 1; i <= 3; i++ {
+		s.SetState(i)
+	}
+
+	// debounced delivery needs both the worker and a minute of fake time
+	clock.WaitFor(t, func() bool {
+		states := r.States()
+		return len(states) > 0 && states[len(states)-1] == 3
+	}, 2*time.Minute,
+	)
+
+	// a slow worker may let the debounce fire in between, but transitions stay consistent
+	prev := 0
+	for _, tr := range r.Transitions() {
+		if tr.PrevState != prev {
+			t.Fatalf("transitions %+v are not consistent", r.Transitions())
+		}
+		prev = tr.State
+	}
+	if elapsed := clock.Now().Sub(start); elapsed < time.Minute {
+		t.Fatalf("clock advanced by %v, want at least 1m", elapsed)
+	}
+}
+
+// negative tests
+
+func TestClockWaitForTimeout(t *testing.T) {
+	t.Parallel()
+
+	clock := NewClock(time.Unix(0, 0))
+	ft := &fakeT{TB: t}
+	clock.WaitFor(ft, func() bool { return false }, 3*PollInterval)
+
+	if len(ft.failures) != 1 {
+		t.Fatalf("WaitFor reported %d failures, want 1", len(ft.failures))
+	}
+	if got := clock.Now().Sub(time.Unix(0, 0)); got != 3*PollInterval {
+		t.Fatalf("clock advanced by %v, want %v", got, 3*PollInterval)
+	}
+}
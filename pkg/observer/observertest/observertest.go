@@ -0,0 +1,135 @@
+// Package observertest provides utilities for testing code built on observer package.
+package observertest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+)
+
+// Transition is a single (state, prevState) pair received by Observer.
+type Transition[T any] struct {
+	State     T
+	PrevState T
+}
+
+// Recorder is an Observer that records every notification it receives.
+// Recorder is safe for concurrent use, so it can be attached to asynchronous Subject.
+type Recorder[T any] struct {
+	mu     sync.Mutex
+	events []observer.Event[T]
+}
+
+// NewRecorder creates empty Recorder.
+func NewRecorder[T any]() *Recorder[T] {
+	return &Recorder[T]{}
+}
+
+// Update implements observer.Observer interface.
+func (r *Recorder[T]) Update(state, prevState T) {
+	r.OnEvent(observer.Event[T]{State: state, PrevState: prevState})
+}
+
+// OnEvent implements observer.EventObserver interface,
+// so Recorder keeps versions and times of notifications too.
+func (r *Recorder[T]) OnEvent(e observer.Event[T]) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+// Events returns copy of recorded notifications, the oldest first.
+func (r *Recorder[T]) Events() []observer.Event[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]observer.Event[T](nil), r.events...)
+}
+
+// Transitions returns recorded (state, prevState) pairs, the oldest first.
+func (r *Recorder[T]) Transitions() []Transition[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transitions := make([]Transition[T], 0, len(r.events))
+	for _, e := range r.events {
+		transitions = append(transitions, Transition[T]{State: e.State, PrevState: e.PrevState})
+	}
+	return transitions
+}
+
+// States returns recorded states, the oldest first.
+func (r *Recorder[T]) States() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	states := make([]T, 0, len(r.events))
+	for _, e := range r.events {
+		states = append(states, e.State)
+	}
+	return states
+}
+
+// Len returns number of recorded notifications.
+func (r *Recorder[T]) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.events)
+}
+
+// Reset forgets recorded notifications.
+func (r *Recorder[T]) Reset() {
+	r.mu.Lock()
+	r.events = nil
+	r.mu.Unlock()
+}
+
+// ExpectSequence fails t unless r recorded exactly given states, in given order.
+func ExpectSequence[T any](t testing.TB, r *Recorder[T], want ...T) {
+	t.Helper()
+
+	got := r.States()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("observer received states %v, want %v", got, want)
+	}
+}
+
+// ExpectTransitions fails t unless r recorded exactly given transitions, in given order.
+func ExpectTransitions[T any](t testing.TB, r *Recorder[T], want ...Transition[T]) {
+	t.Helper()
+
+	got := r.Transitions()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("observer received transitions %+v, want %+v", got, want)
+	}
+}
+
+// PollInterval is how often WaitFor checks its predicate.
+const PollInterval = time.Millisecond
+
+// WaitFor waits until predicate returns true and fails t if it does not within timeout.
+// It is meant for asynchronous Subject, whose Observer instances are notified
+// after SetState returns.
+// Tests that use fake Clock should wait with Clock.WaitFor instead.
+func WaitFor(t testing.TB, predicate func() bool, timeout time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !predicate() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not satisfied within %v", timeout)
+			return
+		}
+		time.Sleep(PollInterval)
+	}
+}
@@ -0,0 +1,106 @@
+package observertest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+)
+
+// fakeT is a helper testing.TB that records failures instead of stopping the test.
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Fatalf(format string, args ...any) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	s := observer.NewSubject[int]()
+	r := NewRecorder[int]()
+	s.Attach(r)
+
+	s.SetState(1)
+	s.SetState(2)
+
+	ExpectSequence(t, r, 1, 2)
+	ExpectTransitions(t, r, Transition[int]{1, 0}, Transition[int]{2, 1})
+	if events := r.Events(); events[1].Version != 2 {
+		t.Fatalf("recorded version %d, want 2", events[1].Version)
+	}
+
+	r.Reset()
+	ExpectSequence(t, r)
+	if r.Len() != 0 {
+		t.Fatalf("Len() after Reset = %d, want 0", r.Len())
+	}
+}
+
+func TestWaitForAsync(t *testing.T) {
+	t.Parallel()
+
+	s := observer.NewSubject[int](observer.WithAsync[int](8, observer.Block))
+	defer s.Close()
+	r := NewRecorder[int]()
+	s.Attach(r)
+
+	for i := 1; i <= 5; i++ {
+		s.SetState(i)
+	}
+
+	WaitFor(t, func() bool { return r.Len() == 5 }, time.Second)
+	ExpectSequence(t, r, 1, 2, 3, 4, 5)
+}
+
+func TestClockStampsEvents(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	s := observer.NewSubject[int](observer.WithClock[int](clock), observer.WithAsync[int](2, observer.Block))
+	r := NewRecorder[int]()
+	s.Attach(r)
+
+	s.SetState(1)
+	clock.Advance(time.Minute)
+	s.SetState(2)
+	s.Close()
+
+	events := r.Events()
+	if !events[0].Time.Equal(start) || !events[1].Time.Equal(start.Add(time.Minute)) {
+		t.Fatalf("events stamped %v and %v, want %v and %v", events[0].Time, events[1].Time, start, start.Add(time.Minute))
+	}
+}
+
+// negative tests
+
+func TestExpectSequenceMismatch(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecorder[int]()
+	r.Update(1, 0)
+
+	ft := &fakeT{TB: t}
+	ExpectSequence[int](ft, r, 2)
+	ExpectTransitions(ft, r, Transition[int]{1, 1})
+	if len(ft.failures) != 2 {
+		t.Fatalf("mismatches reported %d failures, want 2", len(ft.failures))
+	}
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	t.Parallel()
+
+	ft := &fakeT{TB: t}
+	WaitFor(ft, func() bool { return false }, 5*time.Millisecond)
+	if len(ft.failures) != 1 {
+		t.Fatalf("WaitFor reported %d failures, want 1", len(ft.failures))
+	}
+}
@@ -0,0 +1,11 @@
+package observer
+
+// WithPriority sets priority of attached Observer.
+// Observer instances with higher priority are notified first,
+// Observer instances with equal priority are notified in attach order.
+// Default priority is 0.
+func WithPriority[T any](priority int) AttachOption[T] {
+	return func(sub *subscriber[T]) {
+		sub.priority = priority
+	}
+}
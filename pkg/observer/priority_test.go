@@ -0,0 +1,85 @@
+package observer
+
+import (
+	"reflect"
+	"testing"
+)
+
+// orderObserver is a helper Observer implementation that appends its name
+// to shared log on every Update.
+type orderObserver struct {
+	name string
+	log  *[]string
+}
+
+func (o *orderObserver) Update(_, _ int) {
+	*o.log = append(*o.log, o.name)
+}
+
+func TestWithPriority(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+	s := NewSubject[int]()
+	ui1 := &orderObserver{name: "ui1", log: &log}
+	audit := &orderObserver{name: "audit", log: &log}
+	ui2 := &orderObserver{name: "ui2", log: &log}
+	validate := &orderObserver{name: "validate", log: &log}
+	cleanup := &orderObserver{name: "cleanup", log: &log}
+	audit2 := &orderObserver{name: "audit2", log: &log}
+
+	s.Attach(ui1)
+	s.AttachWith(audit, WithPriority[int](10))
+	s.Attach(ui2)
+	s.AttachWith(validate, WithPriority[int](20))
+	s.AttachWith(cleanup, WithPriority[int](-1))
+	s.AttachWith(audit2, WithPriority[int](10))
+
+	s.SetState(1)
+
+	want := []string{"validate", "audit", "audit2", "ui1", "ui2", "cleanup"}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("observers notified in order %v, want %v", log, want)
+	}
+}
+
+func TestAttachWithUnsubscribe(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o := &testObserver{}
+	sub := s.AttachWith(o, WithPriority[int](5))
+
+	s.SetState(1)
+	sub.Unsubscribe()
+	s.SetState(2)
+
+	if len(o.states) != 1 {
+		t.Fatalf("observer received %d updates, want 1", len(o.states))
+	}
+}
+
+func TestWithPriorityAttachInsideUpdate(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+	s := NewSubject[int]()
+	late := &orderObserver{name: "late", log: &log}
+	first := &orderObserver{name: "first", log: &log}
+	s.Attach(ObserverFunc[int](func(state, _ int) {
+		if state == 1 {
+			s.AttachWith(late, WithPriority[int](100))
+		}
+	},
+	),
+	)
+	s.Attach(first)
+
+	s.SetState(1)
+	s.SetState(2)
+
+	// late observer must not receive the notification it was attached during
+	if want := []string{"first", "late", "first"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("observers notified in order %v, want %v", log, want)
+	}
+}
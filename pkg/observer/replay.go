@@ -0,0 +1,113 @@
+package observer
+
+import (
+	"context"
+)
+
+// WithBehavior makes Subject deliver its current state to every newly attached Observer
+// right away, as Event with zero PrevState and Replay set.
+func WithBehavior[T any]() Option[T] {
+	return func(s *Subject[T]) {
+		s.behavior = true
+	}
+}
+
+// WithReplay makes Subject keep last n state changes and deliver them
+// to every newly attached Observer right away, as Event instances with Replay set.
+// Combined with WithBehavior, current state is delivered when there is nothing to replay.
+func WithReplay[T any](n int) Option[T] {
+	if n < 1 {
+		panic("replay size must be positive")
+	}
+
+	return func(s *Subject[T]) {
+		s.replaySize = n
+		s.changes = make([]Event[T], 0, n)
+	}
+}
+
+// WithCurrent makes Subject deliver its current state to Observer right away,
+// as Event with zero PrevState and Replay set, even if Subject was not created WithBehavior.
+// Changes that Subject keeps for replay are not delivered to such Observer.
+func WithCurrent[T any]() AttachOption[T] {
+	return func(sub *subscriber[T]) {
+		sub.current = true
+	}
+}
+
+// withoutReplay makes Subject deliver nothing to Observer on attach,
+// for Observer that has already seen current state.
+func withoutReplay[T any]() AttachOption[T] {
+	return func(sub *subscriber[T]) {
+		sub.noReplay = true
+	}
+}
+
+// record keeps state change that just happened for replay.
+// Caller must hold mu.
+func (s *Subject[T]) record(prevState T) {
+	if s.replaySize == 0 {
+		return
+	}
+
+	if len(s.changes) == s.replaySize {
+		s.changes = append(s.changes[:0], s.changes[1:]...)
+	}
+	s.changes = append(s.changes, Event[T]{
+		State:     s.state,
+		PrevState: prevState,
+		Version:   s.version,
+		Time:      s.changed,
+		Replay:    true,
+	},
+	)
+}
+
+// replayed returns changes to deliver to newly attached Observer.
+// Caller must hold mu.
+func (s *Subject[T]) replayed() []Event[T] {
+	if len(s.changes) > 0 {
+		return append([]Event[T](nil), s.changes...)
+	}
+	if s.behavior {
+		return []Event[T]{s.current()}
+	}
+	return nil
+}
+
+// current returns current state as Event to replay.
+// Caller must hold mu.
+func (s *Subject[T]) current() Event[T] {
+	return Event[T]{
+		State:   s.state,
+		Version: s.version,
+		Time:    s.changed,
+		Replay:  true,
+	}
+}
+
+// replay delivers replayed changes to Observer instances attached by current subscription.
+// Changes made after attach wait until replay is done.
+func (s *subscription[T]) replay() {
+	for _, sub := range s.subscribers {
+		if sub.ready != nil {
+			s.subject.replayTo(sub)
+		}
+	}
+}
+
+// replayTo delivers pending changes to sub.
+func (s *Subject[T]) replayTo(sub *subscriber[T]) {
+	defer close(sub.ready)
+
+	pending := sub.pending
+	sub.pending = nil
+	for _, e := range pending {
+		if sub.isDetached() {
+			return
+		}
+		if err := s.deliver(context.Background(), sub, e); err != nil {
+			s.report(err)
+		}
+	}
+}
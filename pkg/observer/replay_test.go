@@ -0,0 +1,295 @@
+package observer
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// eventRecorder is a helper EventObserver implementation that stores every event.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event[int]
+}
+
+func (r *eventRecorder) Update(state, prevState int) {
+	r.OnEvent(Event[int]{State: state, PrevState: prevState})
+}
+
+func (r *eventRecorder) OnEvent(e Event[int]) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+// transitions returns (state, prevState, replay) of recorded events.
+func (r *eventRecorder) transitions() [][3]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	got := make([][3]int, 0, len(r.events))
+	for _, e := range r.events {
+		replay := 0
+		if e.Replay {
+			replay = 1
+		}
+		got = append(got, [3]int{e.State, e.PrevState, replay})
+	}
+	return got
+}
+
+func TestWithBehavior(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithBehavior[int]())
+	s.SetState(1)
+	s.SetState(2)
+
+	r := &eventRecorder{}
+	s.Attach(r)
+	s.SetState(3)
+
+	if want := [][3]int{{2, 0, 1}, {3, 2, 0}}; !reflect.DeepEqual(r.transitions(), want) {
+		t.Fatalf("late observer received %v, want %v", r.transitions(), want)
+	}
+	if r.events[0].Version != 2 {
+		t.Fatalf("replayed event has version %d, want 2", r.events[0].Version)
+	}
+}
+
+func TestWithBehaviorPlainObserver(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithBehavior[int]())
+	s.SetState(5)
+
+	o := &transitionObserver{}
+	s.Attach(o)
+
+	if want := [][2]int{{5, 0}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("late observer received %v, want %v", o.transitions, want)
+	}
+}
+
+func TestWithReplay(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithReplay[int](2))
+	for i := 1; i <= 4; i++ {
+		s.SetState(i)
+	}
+
+	r := &eventRecorder{}
+	s.Attach(r)
+	s.SetState(5)
+
+	if want := [][3]int{{3, 2, 1}, {4, 3, 1}, {5, 4, 0}}; !reflect.DeepEqual(r.transitions(), want) {
+		t.Fatalf("late observer received %v, want %v", r.transitions(), want)
+	}
+}
+
+func TestWithReplayAndBehaviorEmpty(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithReplay[int](2), WithBehavior[int]())
+	r := &eventRecorder{}
+	s.Attach(r)
+
+	if want := [][3]int{{0, 0, 1}}; !reflect.DeepEqual(r.transitions(), want) {
+		t.Fatalf("observer of new subject received %v, want %v", r.transitions(), want)
+	}
+}
+
+func TestWithReplayFilter(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithReplay[int](4))
+	for i := 1; i <= 4; i++ {
+		s.SetState(i)
+	}
+
+	o := &testObserver{}
+	s.AttachWith(o, WithFilter(func(state, _ int) bool { return state%2 == 0 }))
+
+	if want := []int{2, 4}; !reflect.DeepEqual(o.states, want) {
+		t.Fatalf("filtered late observer received %v, want %v", o.states, want)
+	}
+}
+
+func TestWithReplayAsync(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithAsync[int](1, Block), WithReplay[int](3))
+	for i := 1; i <= 3; i++ {
+		s.SetState(i)
+	}
+
+	o := &transitionObserver{}
+	s.Attach(o)
+	s.SetState(4)
+	s.Close()
+
+	if want := [][2]int{{1, 0}, {2, 1}, {3, 2}, {4, 3}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("late asynchronous observer received %v, want %v", o.transitions, want)
+	}
+}
+
+func TestWithReplayAsyncOverflow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+		want   [][3]int
+	}{
+		{
+			name:   "Coalesce",
+			policy: Coalesce,
+			want:   [][3]int{{1, 0, 1}, {2, 1, 1}, {3, 2, 1}, {5, 3, 0}},
+		},
+		{
+			name:   "DropNewest",
+			policy: DropNewest,
+			want:   [][3]int{{1, 0, 1}, {2, 1, 1}, {3, 2, 1}, {4, 3, 0}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewSubject[int](WithAsync[int](1, tt.policy), WithReplay[int](3))
+			for i := 1; i <= 3; i++ {
+				s.SetState(i)
+			}
+
+			// worker is busy with the first replayed change,
+			// the other two must not take room of changes made meanwhile
+			r := &eventRecorder{}
+			started, release := make(chan struct{}), make(chan struct{})
+			var once sync.Once
+			s.Attach(EventFunc[int](func(e Event[int]) {
+				once.Do(func() {
+					close(started)
+					<-release
+				},
+				)
+				r.OnEvent(e)
+			},
+			),
+			)
+			<-started
+			s.SetState(4)
+			s.SetState(5)
+			close(release)
+			s.Close()
+
+			if got := r.transitions(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("late asynchronous observer received %v, want %v", got, tt.want)
+			}
+		},
+		)
+	}
+}
+
+func TestWithReplayConcurrentAttach(t *testing.T) {
+	t.Parallel()
+
+	const (
+		observers = 16
+		updates   = 200
+	)
+
+	s := NewSubject[int](WithReplay[int](1))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= updates; i++ {
+			s.SetState(i)
+		}
+	}()
+
+	recorders := make([]*transitionObserver, observers)
+	for i := range recorders {
+		recorders[i] = &transitionObserver{}
+		s.Attach(recorders[i])
+	}
+	wg.Wait()
+
+	// every observer must see a gapless, duplicate-free chain ending with the last state
+	for i, o := range recorders {
+		for j := 1; j < len(o.transitions); j++ {
+			if o.transitions[j][1] != o.transitions[j-1][0] {
+				t.Fatalf("observer %d: transition %v does not follow %v", i, o.transitions[j], o.transitions[j-1])
+			}
+		}
+		if n := len(o.transitions); n > 0 && o.transitions[n-1][0] != updates {
+			t.Fatalf("observer %d: last state %d, want %d", i, o.transitions[n-1][0], updates)
+		}
+	}
+}
+
+func TestSubscribeReplay(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithReplay[int](2))
+	s.SetState(1)
+	s.SetState(2)
+
+	ch := s.Subscribe(context.Background(), 0)
+	go s.SetState(3)
+
+	for i := 1; i <= 3; i++ {
+		got, ok := receive(t, ch)
+		if !ok || got.State != i || got.Replay != (i < 3) {
+			t.Fatalf("received %+v, want state %d", got, i)
+		}
+	}
+}
+
+func TestWithCurrent(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithReplay[int](2))
+	s.SetState(1)
+	s.SetState(2)
+
+	r := &eventRecorder{}
+	s.AttachWith(r, WithCurrent[int]())
+	s.SetState(3)
+
+	if want := [][3]int{{2, 0, 1}, {3, 2, 0}}; !reflect.DeepEqual(r.transitions(), want) {
+		t.Fatalf("observer received %v, want %v", r.transitions(), want)
+	}
+}
+
+func TestSubscribeWithCurrent(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	ch := s.SubscribeWith(context.Background(), 0, WithCurrent[int]())
+	go s.SetState(1)
+
+	for i := 0; i <= 1; i++ {
+		got, ok := receive(t, ch)
+		if !ok || got.State != i || got.Replay != (i == 0) || got.Version != uint64(i) {
+			t.Fatalf("received %+v, want state %d", got, i)
+		}
+	}
+}
+
+// negative tests
+
+func TestWithReplayZeroShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic on zero replay size, got none")
+		}
+	}()
+	WithReplay[int](0)
+}
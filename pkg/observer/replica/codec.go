@@ -0,0 +1,70 @@
+package replica
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+)
+
+// MaxFrameSize is the largest frame accepted from the wire.
+const MaxFrameSize = 16 << 20
+
+// ErrFrameTooLarge is returned when frame size exceeds MaxFrameSize.
+var ErrFrameTooLarge = errors.New("replica: frame too large")
+
+// message is a single replicated state.
+// Snapshot is set on the first message of every connection,
+// follower accepts it regardless of its version.
+type message[T any] struct {
+	Version  uint64
+	Snapshot bool
+	State    T
+}
+
+// writeMessage encodes m with codec and writes it to w as a single frame:
+// 4 bytes of big-endian payload length followed by the payload.
+func writeMessage[T any](w io.Writer, codec observer.Codec, m message[T]) error {
+	payload, err := codec.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if len(payload) > MaxFrameSize {
+		return ErrFrameTooLarge
+	}
+
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+	_, err = w.Write(frame)
+	return err
+}
+
+// readMessage reads a single frame from r and decodes it with codec.
+// io.EOF is returned only if r ends between frames.
+func readMessage[T any](r io.Reader, codec observer.Codec) (message[T], error) {
+	var m message[T]
+
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return m, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxFrameSize {
+		return m, ErrFrameTooLarge
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return m, err
+	}
+	if err := codec.Unmarshal(payload, &m); err != nil {
+		return m, fmt.Errorf("replica: decode frame: %w", err)
+	}
+	return m, nil
+}
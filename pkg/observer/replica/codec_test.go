@@ -0,0 +1,87 @@
+package replica
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+)
+
+type replicated struct {
+	Name  string
+	Items []int
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for name, codec := range map[string]observer.Codec{"JSON": observer.JSON, "Gob": observer.Gob} {
+		codec := codec
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			want := []message[replicated]{
+				{Version: 3, Snapshot: true, State: replicated{Name: "a", Items: []int{1}}},
+				{Version: 4, State: replicated{Name: "b", Items: []int{1, 2}}},
+			}
+			for _, m := range want {
+				if err := writeMessage(&buf, codec, m); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, w := range want {
+				m, err := readMessage[replicated](&buf, codec)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if m.Version != w.Version || m.Snapshot != w.Snapshot || m.State.Name != w.State.Name || len(m.State.Items) != len(w.State.Items) {
+					t.Fatalf("read %+v, want %+v", m, w)
+				}
+			}
+			if _, err := readMessage[replicated](&buf, codec); err != io.EOF {
+				t.Fatalf("read past last frame returned %v, want io.EOF", err)
+			}
+		},
+		)
+	}
+}
+
+// negative tests
+
+func TestReadMessageTooLarge(t *testing.T) {
+	t.Parallel()
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], MaxFrameSize+1)
+	if _, err := readMessage[int](bytes.NewReader(header[:]), observer.JSON); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("readMessage() = %v, want %v", err, ErrFrameTooLarge)
+	}
+}
+
+func TestReadMessageTruncated(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := writeMessage(&buf, observer.JSON, message[int]{Version: 1, State: 1}); err != nil {
+		t.Fatal(err)
+	}
+	buf.Truncate(buf.Len() - 1)
+
+	if _, err := readMessage[int](&buf, observer.JSON); err != io.ErrUnexpectedEOF {
+		t.Fatalf("readMessage() = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestReadMessageCorrupted(t *testing.T) {
+	t.Parallel()
+
+	frame := []byte{0, 0, 0, 1, '{'}
+	if _, err := readMessage[int](bytes.NewReader(frame), observer.JSON); err == nil {
+		t.Fatalf("readMessage() of corrupted frame returned no error")
+	}
+}
@@ -0,0 +1,205 @@
+// Package replica mirrors state of observer.Subject to other processes.
+//
+// Primary writes state changes of its Subject to any io.Writer (e.g. net.Conn)
+// as length-prefixed frames encoded with observer.Codec,
+// Follower reads them and applies to its own Subject.
+// Every connection starts with a snapshot of current state,
+// so a reconnected Follower resyncs, and updates that are not newer
+// than the last applied version are discarded.
+package replica
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+)
+
+// Primary replicates state of a Subject to connected followers.
+type Primary[T any] struct {
+	subject *observer.Subject[T]
+	codec   observer.Codec
+	bufSize int
+}
+
+// NewPrimary creates Primary that replicates state of s encoded with codec.
+// bufSize changes are buffered for every connection, when the buffer is full
+// synchronous Subject waits for the connection like it does for Observer instances.
+func NewPrimary[T any](s *observer.Subject[T], codec observer.Codec, bufSize int) *Primary[T] {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+	if codec == nil {
+		panic("codec is nil")
+	}
+
+	return &Primary[T]{
+		subject: s,
+		codec:   codec,
+		bufSize: bufSize,
+	}
+}
+
+// Serve writes snapshot of current state to w, followed by every state change,
+// until ctx is done, Subject is closed or write fails.
+// If w is an io.Closer, it is closed once ctx is done to unblock writing.
+// Serve returns write error, or nil once Subject is closed, or ctx error.
+func (p *Primary[T]) Serve(ctx context.Context, w io.Writer) error {
+	if c, ok := w.(io.Closer); ok {
+		defer closeOnDone(ctx, c)()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for e := range p.subject.SubscribeWith(ctx, p.bufSize, observer.WithCurrent[T]()) {
+		err := writeMessage(w, p.codec, message[T]{
+			Version:  e.Version,
+			Snapshot: e.Replay,
+			State:    e.State,
+		},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// ServeListener accepts connections from ln and serves each of them
+// until ctx is done or ln fails. Connections are closed once served.
+func (p *Primary[T]) ServeListener(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			_ = p.Serve(ctx, conn)
+		}()
+	}
+}
+
+// Follower applies state replicated by Primary to a Subject.
+type Follower[T any] struct {
+	version uint64 // accessed atomically, first for 64-bit alignment
+
+	subject *observer.Subject[T]
+	codec   observer.Codec
+
+	mu     sync.Mutex // serializes applied messages
+	synced bool
+}
+
+// NewFollower creates Follower that applies state decoded with codec to s.
+// s should be created observer.WithDistinct, so snapshot equal
+// to the current state does not notify Observer instances on reconnect.
+func NewFollower[T any](s *observer.Subject[T], codec observer.Codec) *Follower[T] {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+	if codec == nil {
+		panic("codec is nil")
+	}
+
+	return &Follower[T]{
+		subject: s,
+		codec:   codec,
+	}
+}
+
+// Version returns version of the last applied state, as numbered by Primary.
+func (f *Follower[T]) Version() uint64 {
+	return atomic.LoadUint64(&f.version)
+}
+
+// Run reads replicated state from r and applies it until r ends, read fails or ctx is done.
+// If r is an io.Closer, it is closed once ctx is done to unblock reading.
+// Run returns nil once r ends between frames.
+func (f *Follower[T]) Run(ctx context.Context, r io.Reader) error {
+	if c, ok := r.(io.Closer); ok {
+		defer closeOnDone(ctx, c)()
+	}
+
+	for {
+		m, err := readMessage[T](r, f.codec)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		f.apply(m)
+	}
+}
+
+// Connect keeps Follower connected to Primary: it dials, runs Follower
+// until connection ends and dials again after retry delay, until ctx is done.
+// Connect returns ctx error.
+func (f *Follower[T]) Connect(ctx context.Context, dial func(ctx context.Context) (net.Conn, error), retry time.Duration) error {
+	for {
+		if conn, err := dial(ctx); err == nil {
+			_ = f.Run(ctx, conn)
+			conn.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retry):
+		}
+	}
+}
+
+// apply sets state of m unless it is stale.
+// Snapshot is always applied, Primary may have restarted with lower versions.
+func (f *Follower[T]) apply(m message[T]) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !m.Snapshot && f.synced && m.Version <= f.Version() {
+		return
+	}
+
+	atomic.StoreUint64(&f.version, m.Version)
+	f.synced = true
+	f.subject.SetState(m.State)
+}
+
+// closeOnDone closes c once ctx is done.
+// Returned function stops waiting for ctx.
+func closeOnDone(ctx context.Context, c io.Closer) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,233 @@
+package replica
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+)
+
+// transitions is a helper Observer that stores every (state, prevState) pair.
+type transitions struct {
+	mu    sync.Mutex
+	pairs [][2]int
+}
+
+func (o *transitions) Update(state, prevState int) {
+	o.mu.Lock()
+	o.pairs = append(o.pairs, [2]int{state, prevState})
+	o.mu.Unlock()
+}
+
+func (o *transitions) get() [][2]int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([][2]int(nil), o.pairs...)
+}
+
+// eventually waits (with a timeout) for condition f to become true.
+func eventually(t *testing.T, f func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not satisfied within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// link serves primary to follower over an in-memory connection
+// and returns function that disconnects them.
+func link(t *testing.T, p *Primary[int], f *Follower[int]) (disconnect func()) {
+	t.Helper()
+
+	server, client := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_ = p.Serve(ctx, server)
+	}()
+	go func() {
+		defer wg.Done()
+		_ = f.Run(ctx, client)
+	}()
+
+	disconnect = func() {
+		cancel()
+		wg.Wait()
+	}
+	t.Cleanup(disconnect)
+	return disconnect
+}
+
+func TestReplicate(t *testing.T) {
+	t.Parallel()
+
+	primary := observer.NewSubject[int]()
+	primary.SetState(1)
+	follower := observer.NewSubject[int](observer.WithDistinct[int]())
+	o := &transitions{}
+	follower.Attach(o)
+
+	f := NewFollower[int](follower, observer.Gob)
+	link(t, NewPrimary[int](primary, observer.Gob, 1), f)
+
+	eventually(t, func() bool { return f.Version() == 1 })
+	primary.SetState(2)
+	primary.SetState(3)
+	eventually(t, func() bool { return f.Version() == 3 })
+
+	if want := [][2]int{{1, 0}, {2, 1}, {3, 2}}; !reflect.DeepEqual(o.get(), want) {
+		t.Fatalf("follower observer received %v, want %v", o.get(), want)
+	}
+}
+
+func TestReconnectResync(t *testing.T) {
+	t.Parallel()
+
+	primary := observer.NewSubject[int]()
+	p := NewPrimary[int](primary, observer.JSON, 1)
+	follower := observer.NewSubject[int](observer.WithDistinct[int]())
+	o := &transitions{}
+	follower.Attach(o)
+	f := NewFollower[int](follower, observer.JSON)
+
+	disconnect := link(t, p, f)
+	primary.SetState(1)
+	eventually(t, func() bool { return f.Version() == 1 })
+	disconnect()
+
+	primary.SetState(2)
+	primary.SetState(3)
+	link(t, p, f)
+	eventually(t, func() bool { return f.Version() == 3 })
+
+	if want := [][2]int{{1, 0}, {3, 1}}; !reflect.DeepEqual(o.get(), want) {
+		t.Fatalf("follower observer received %v, want %v", o.get(), want)
+	}
+}
+
+func TestFollowerDiscardsStale(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	for _, m := range []message[int]{
+		{Version: 5, Snapshot: true, State: 5},
+		{Version: 4, State: 4},
+		{Version: 5, State: 50},
+		{Version: 6, State: 6},
+		{Version: 1, Snapshot: true, State: 1}, // primary restarted
+		{Version: 2, State: 2},
+	} {
+		if err := writeMessage(&buf, observer.JSON, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := observer.NewSubject[int]()
+	o := &transitions{}
+	s.Attach(o)
+	f := NewFollower[int](s, observer.JSON)
+	if err := f.Run(context.Background(), &buf); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+
+	if want := [][2]int{{5, 0}, {6, 5}, {1, 6}, {2, 1}}; !reflect.DeepEqual(o.get(), want) {
+		t.Fatalf("observer received %v, want %v", o.get(), want)
+	}
+	if got := f.Version(); got != 2 {
+		t.Fatalf("Version() = %d, want 2", got)
+	}
+}
+
+func TestServeListenerConnect(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	primary := observer.NewSubject[int]()
+	primary.SetState(7)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = NewPrimary[int](primary, observer.Gob, 1).ServeListener(ctx, ln)
+	}()
+
+	f := NewFollower[int](observer.NewSubject[int](), observer.Gob)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var d net.Dialer
+		_ = f.Connect(ctx, func(ctx context.Context) (net.Conn, error) {
+			return d.DialContext(ctx, "tcp", ln.Addr().String())
+		}, time.Millisecond)
+	}()
+
+	eventually(t, func() bool { return f.Version() == 1 })
+	primary.SetState(8)
+	eventually(t, func() bool { return f.Version() == 2 })
+}
+
+func TestServeClosedSubject(t *testing.T) {
+	t.Parallel()
+
+	s := observer.NewSubject[int]()
+	s.SetState(1)
+	s.Close()
+
+	var buf bytes.Buffer
+	if err := NewPrimary[int](s, observer.JSON, 1).Serve(context.Background(), &buf); err != nil {
+		t.Fatalf("Serve() of closed subject = %v, want nil", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Serve() of closed subject wrote %d bytes", buf.Len())
+	}
+}
+
+// negative tests
+
+func TestRunCorrupted(t *testing.T) {
+	t.Parallel()
+
+	f := NewFollower[int](observer.NewSubject[int](), observer.JSON)
+	if err := f.Run(context.Background(), bytes.NewReader([]byte{0, 0, 0, 1, '{'})); err == nil {
+		t.Fatalf("Run() of corrupted stream returned no error")
+	}
+}
+
+func TestNilSubjectShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	check := func(name string, f func()) {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("%s did not panic on nil subject", name)
+			}
+		}()
+		f()
+	}
+
+	check("NewPrimary", func() { NewPrimary[int](nil, observer.JSON, 1) })
+	check("NewFollower", func() { NewFollower[int](nil, observer.JSON) })
+}
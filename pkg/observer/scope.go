@@ -0,0 +1,61 @@
+package observer
+
+import (
+	"context"
+	"runtime"
+)
+
+// WithContext makes attached Observer live only as long as ctx,
+// it is detached automatically once ctx is done.
+func WithContext[T any](ctx context.Context) AttachOption[T] {
+	if ctx == nil {
+		panic("context is nil")
+	}
+
+	return func(sub *subscriber[T]) {
+		sub.owner = ctx
+	}
+}
+
+// watch detaches sub once its owner context is done.
+func (s *Subject[T]) watch(sub *subscriber[T]) {
+	select {
+	case <-sub.owner.Done():
+		s.remove(func(other *subscriber[T]) bool { return other == sub })
+	case <-sub.gone:
+	}
+}
+
+// weakSubscription detaches its Observer instances once it is garbage collected.
+type weakSubscription[T any] struct {
+	sub *subscription[T]
+}
+
+// Unsubscribe detaches Observer instances attached by AttachWeak call.
+func (w *weakSubscription[T]) Unsubscribe() {
+	runtime.SetFinalizer(w, nil)
+	w.sub.Unsubscribe()
+}
+
+// AttachWeak adds new Observer instances to current Subject and returns
+// Subscription that detaches them once it becomes unreachable, e.g. when
+// short-lived component that keeps it is garbage collected.
+// Subject does not reference returned Subscription, but keeps Observer instances,
+// so they must not reference the owner of Subscription, otherwise it is never collected.
+// Detaching happens some time after the owner is collected, not right away.
+// Subject must be initialized before AttachWeak calls.
+func (s *Subject[T]) AttachWeak(observers ...Observer[T]) Subscription {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	sub, _ := s.attach(observers)
+	sub.replay()
+
+	w := &weakSubscription[T]{sub: sub}
+	runtime.SetFinalizer(w, func(w *weakSubscription[T]) {
+		w.sub.Unsubscribe()
+	},
+	)
+	return w
+}
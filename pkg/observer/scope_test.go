@@ -0,0 +1,141 @@
+package observer
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWithContext(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	o := &testObserver{}
+	s.AttachWith(o, WithContext[int](ctx))
+
+	s.SetState(1)
+	cancel()
+	eventually(t, time.Second, func() bool { return len(attached(s)) == 0 })
+	s.SetState(2)
+
+	if len(o.states) != 1 {
+		t.Fatalf("observer received %d updates, want 1 before its context was done", len(o.states))
+	}
+}
+
+func TestWithContextDone(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s.AttachWith(&testObserver{}, WithContext[int](ctx))
+	eventually(t, time.Second, func() bool { return len(attached(s)) == 0 })
+}
+
+func TestWithContextUnsubscribe(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := s.AttachWith(&testObserver{}, WithContext[int](ctx))
+	sub.Unsubscribe()
+	s.Close()
+
+	if len(attached(s)) != 0 {
+		t.Fatalf("observer is still attached after Unsubscribe")
+	}
+}
+
+// component is a short-lived owner of a weak subscription.
+type component struct {
+	sub Subscription
+}
+
+func TestAttachWeak(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o := &testObserver{}
+
+	func() {
+		c := &component{sub: s.AttachWeak(o)}
+		s.SetState(1)
+		runtime.KeepAlive(c)
+	}()
+
+	eventually(t, time.Second, func() bool {
+		runtime.GC()
+		return len(attached(s)) == 0
+	},
+	)
+	s.SetState(2)
+
+	if len(o.states) != 1 {
+		t.Fatalf("observer received %d updates, want 1 before its owner was collected", len(o.states))
+	}
+}
+
+// awaitFinalizers runs garbage collection until finalizers of objects
+// that were unreachable before the call are done.
+// Finalizers queued by one collection run before ones queued by a later collection,
+// so it waits for a sentinel collected by a second round.
+func awaitFinalizers(t *testing.T) {
+	t.Helper()
+
+	for round := 0; round < 2; round++ {
+		done := make(chan struct{})
+		func() {
+			sentinel := new([64]byte) // large enough to skip the tiny allocator
+			runtime.SetFinalizer(sentinel, func(*[64]byte) { close(done) })
+		}()
+
+		deadline := time.After(time.Second)
+		for finalized := false; !finalized; {
+			runtime.GC()
+			select {
+			case <-done:
+				finalized = true
+			case <-deadline:
+				t.Fatalf("finalizer of sentinel did not run within 1s")
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+}
+
+func TestAttachWeakKeptAlive(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	c := &component{sub: s.AttachWeak(&testObserver{})}
+
+	awaitFinalizers(t)
+
+	if len(attached(s)) != 1 {
+		t.Fatalf("observer of reachable owner was detached")
+	}
+
+	c.sub.Unsubscribe()
+	if len(attached(s)) != 0 {
+		t.Fatalf("observer is still attached after Unsubscribe")
+	}
+}
+
+// negative tests
+
+func TestWithContextNilShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic on nil context, got none")
+		}
+	}()
+	WithContext[int](nil)
+}
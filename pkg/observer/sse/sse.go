@@ -0,0 +1,145 @@
+// Package sse streams state changes of observer.Subject to HTTP clients
+// with Server-Sent Events.
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+)
+
+// DefaultHeartbeat is the interval of heartbeat comments used by default.
+const DefaultHeartbeat = 15 * time.Second
+
+// Handler is an http.Handler that streams state changes of Subject to SSE clients.
+// Every client receives current state of Subject first, then every change,
+// each as "state" event with JSON-encoded Message and Version as event id.
+// Client is detached from Subject once it disconnects,
+// stream ends once Subject is closed.
+//
+// Synchronous Subject waits for slow clients, like it does for Observer instances,
+// so Subject created observer.WithAsync is recommended.
+type Handler[T any] struct {
+	subject   *observer.Subject[T]
+	heartbeat time.Duration
+	bufSize   int
+}
+
+// Message is the data of a single event sent to clients.
+type Message[T any] struct {
+	State     T         `json:"state"`
+	PrevState T         `json:"prevState"`
+	Version   uint64    `json:"version"`
+	Time      time.Time `json:"time"`
+	Initial   bool      `json:"initial,omitempty"`
+}
+
+// Option configures Handler created by NewHandler.
+type Option[T any] func(*Handler[T])
+
+// WithHeartbeat makes Handler send heartbeat comments to idle clients every d,
+// so proxies do not close the connection and disconnects are detected.
+// Zero d disables heartbeat.
+func WithHeartbeat[T any](d time.Duration) Option[T] {
+	if d < 0 {
+		panic("heartbeat interval must not be negative")
+	}
+
+	return func(h *Handler[T]) {
+		h.heartbeat = d
+	}
+}
+
+// WithBuffer sets number of changes buffered for every client.
+func WithBuffer[T any](size int) Option[T] {
+	if size < 1 {
+		panic("buffer size must be positive")
+	}
+
+	return func(h *Handler[T]) {
+		h.bufSize = size
+	}
+}
+
+// NewHandler creates Handler that streams changes of s.
+// Options, if any, configure Handler behaviour.
+func NewHandler[T any](s *observer.Subject[T], opts ...Option[T]) *Handler[T] {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	h := &Handler[T]{
+		subject:   s,
+		heartbeat: DefaultHeartbeat,
+		bufSize:   1,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *Handler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events := h.subject.SubscribeWith(r.Context(), h.bufSize, observer.WithCurrent[T]())
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var heartbeat <-chan time.Time
+	if h.heartbeat > 0 {
+		ticker := time.NewTicker(h.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := write(w, e); err != nil {
+				return
+			}
+		case <-heartbeat:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// write sends e to client as a single "state" event.
+func write[T any](w http.ResponseWriter, e observer.Event[T]) error {
+	data, err := json.Marshal(Message[T]{
+		State:     e.State,
+		PrevState: e.PrevState,
+		Version:   e.Version,
+		Time:      e.Time,
+		Initial:   e.Replay,
+	},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: state\ndata: %s\n\n", e.Version, data)
+	return err
+}
@@ -0,0 +1,214 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kyosheek/go-patterns/pkg/observer"
+)
+
+// serve starts test server that is closed after clients of the test disconnect.
+func serve(t *testing.T, h http.Handler) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// client is a helper SSE client reading events of a single stream.
+type client struct {
+	t      *testing.T
+	resp   *http.Response
+	lines  chan string
+	cancel context.CancelFunc
+}
+
+func connect(t *testing.T, url string) *client {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	c := &client{t: t, resp: resp, lines: make(chan string), cancel: cancel}
+	go func() {
+		defer close(c.lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			select {
+			case c.lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	t.Cleanup(c.close)
+	return c
+}
+
+func (c *client) close() {
+	c.cancel()
+	c.resp.Body.Close()
+}
+
+// next returns lines of the next event or comment, ok is false once stream ends.
+func (c *client) next() (block []string, ok bool) {
+	c.t.Helper()
+
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return block, false
+			}
+			if line == "" {
+				return block, true
+			}
+			block = append(block, line)
+		case <-time.After(time.Second):
+			c.t.Fatalf("no event received within 1s")
+		}
+	}
+}
+
+// message returns the next "state" event.
+func (c *client) message() Message[int] {
+	c.t.Helper()
+
+	block, ok := c.next()
+	if !ok || len(block) != 3 || block[1] != "event: state" || !strings.HasPrefix(block[2], "data: ") {
+		c.t.Fatalf("received %q, want state event", block)
+	}
+
+	var m Message[int]
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(block[2], "data: ")), &m); err != nil {
+		c.t.Fatal(err)
+	}
+	if want := "id: " + strconv.FormatUint(m.Version, 10); block[0] != want {
+		c.t.Fatalf("event id %q, want %q", block[0], want)
+	}
+	return m
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	s := observer.NewSubject[int]()
+	s.SetState(1)
+	srv := serve(t, NewHandler[int](s))
+
+	c := connect(t, srv.URL)
+	if m := c.message(); m.State != 1 || m.Version != 1 || !m.Initial {
+		t.Fatalf("initial message %+v, want state 1 of version 1", m)
+	}
+
+	go func() {
+		s.SetState(2)
+		s.SetState(3)
+	}()
+	for i := 2; i <= 3; i++ {
+		if m := c.message(); m.State != i || m.PrevState != i-1 || m.Initial {
+			t.Fatalf("message %+v, want transition %d -> %d", m, i-1, i)
+		}
+	}
+
+	s.Close()
+	if block, ok := c.next(); ok {
+		t.Fatalf("received %q after Close, want end of stream", block)
+	}
+}
+
+func TestHandlerHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	s := observer.NewSubject[int]()
+	srv := serve(t, NewHandler[int](s, WithHeartbeat[int](10*time.Millisecond)))
+
+	c := connect(t, srv.URL)
+	c.message()
+	if block, ok := c.next(); !ok || len(block) != 1 || block[0] != ": heartbeat" {
+		t.Fatalf("received %q, want heartbeat", block)
+	}
+}
+
+// detachCounter is a helper Instrumentation that counts attached Observer instances.
+type detachCounter struct {
+	observers int64
+}
+
+func (d *detachCounter) Attached(n int)              { atomic.AddInt64(&d.observers, int64(n)) }
+func (d *detachCounter) Detached(n int)              { atomic.AddInt64(&d.observers, -int64(n)) }
+func (d *detachCounter) Notified(any, time.Duration) {}
+func (d *detachCounter) Fired(time.Duration)         {}
+func (d *detachCounter) Panicked(any)                {}
+func (d *detachCounter) Dropped(any, int)            {}
+
+func TestHandlerDisconnect(t *testing.T) {
+	t.Parallel()
+
+	counter := &detachCounter{}
+	s := observer.NewSubject[int](observer.WithInstrumentation[int](counter))
+	srv := serve(t, NewHandler[int](s))
+
+	c := connect(t, srv.URL)
+	c.message()
+	if got := atomic.LoadInt64(&counter.observers); got != 1 {
+		t.Fatalf("subject has %d observers, want 1", got)
+	}
+
+	c.close()
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&counter.observers) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("client was not detached after disconnect")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// negative tests
+
+// plainWriter is an http.ResponseWriter that does not support flushing.
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+func TestHandlerWithoutFlusher(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	NewHandler[int](observer.NewSubject[int]()).ServeHTTP(plainWriter{rec}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestNewHandlerNilSubjectShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("NewHandler(nil) did not panic")
+		}
+	}()
+	NewHandler[int](nil)
+}
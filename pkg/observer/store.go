@@ -0,0 +1,224 @@
+package observer
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store keeps state of Subject between process restarts.
+// Store must be safe for concurrent use.
+type Store[T any] interface {
+	// Load returns saved state, ok is false if nothing was saved yet.
+	Load() (state T, ok bool, err error)
+	// Save replaces saved state.
+	Save(state T) error
+}
+
+// StoreError describes failure of Store used by Subject.
+type StoreError struct {
+	Op  string // "load" or "save"
+	Err error
+}
+
+// Error implements error interface.
+func (e *StoreError) Error() string {
+	return fmt.Sprintf("store %s failed: %v", e.Op, e.Err)
+}
+
+// Unwrap returns underlying error.
+func (e *StoreError) Unwrap() error {
+	return e.Err
+}
+
+// WithStore makes Subject load its initial state from store
+// and save every state change to store before Observer instances are notified.
+// State changes that are skipped as equal and changes made inside
+// an uncommitted Tx are not saved.
+// Store failures are reported to error handler as *StoreError,
+// they do not stop state change or notifications.
+func WithStore[T any](store Store[T]) Option[T] {
+	if store == nil {
+		panic("store is nil")
+	}
+
+	return func(s *Subject[T]) {
+		s.store = store
+	}
+}
+
+// restore loads initial state of Subject from its Store.
+func (s *Subject[T]) restore() {
+	if s.store == nil {
+		return
+	}
+
+	state, ok, err := s.store.Load()
+	if err != nil {
+		s.fail(&StoreError{Op: "load", Err: err})
+		return
+	}
+	if ok {
+		s.state = state
+	}
+}
+
+// persist saves state to Store of Subject.
+// Caller must hold notifyMu, so states are saved in order of changes.
+func (s *Subject[T]) persist(state T) {
+	if s.store == nil {
+		return
+	}
+
+	if err := s.store.Save(state); err != nil {
+		s.fail(&StoreError{Op: "save", Err: err})
+	}
+}
+
+// MemoryStore is a Store that keeps state in memory.
+// It is mostly useful for tests and for sharing state between Subject instances.
+type MemoryStore[T any] struct {
+	mu    sync.Mutex
+	state T
+	ok    bool
+}
+
+// NewMemoryStore creates empty MemoryStore.
+func NewMemoryStore[T any]() *MemoryStore[T] {
+	return &MemoryStore[T]{}
+}
+
+// Load implements Store interface.
+func (m *MemoryStore[T]) Load() (T, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.state, m.ok, nil
+}
+
+// Save implements Store interface.
+func (m *MemoryStore[T]) Save(state T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.state, m.ok = state, true
+	return nil
+}
+
+// Codec converts state to bytes and back.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSON is a Codec that uses encoding/json.
+var JSON Codec = jsonCodec{}
+
+// Gob is a Codec that uses encoding/gob.
+var Gob Codec = gobCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// FileStore is a Store that keeps state in a file encoded with Codec.
+// The file is replaced atomically, so a crash during Save
+// leaves either previous or new state, never a truncated one.
+type FileStore[T any] struct {
+	mu    sync.Mutex
+	path  string
+	codec Codec
+}
+
+// NewFileStore creates FileStore that keeps state in file at path.
+// Directory of the file must exist.
+func NewFileStore[T any](path string, codec Codec) *FileStore[T] {
+	if codec == nil {
+		panic("codec is nil")
+	}
+
+	return &FileStore[T]{
+		path:  path,
+		codec: codec,
+	}
+}
+
+// Load implements Store interface.
+// Missing file means nothing was saved yet.
+func (f *FileStore[T]) Load() (T, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var state T
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, err
+	}
+	if err := f.codec.Unmarshal(data, &state); err != nil {
+		return state, false, err
+	}
+	return state, true, nil
+}
+
+// Save implements Store interface.
+// State is written to a temporary file in the same directory,
+// which then replaces the previous one.
+func (f *FileStore[T]) Save(state T) (err error) {
+	data, err := f.codec.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
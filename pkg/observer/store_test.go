@@ -0,0 +1,156 @@
+package observer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type storeState struct {
+	Name  string
+	Items []int
+}
+
+func TestWithStoreRestore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore[int]()
+	s := NewSubject[int](WithStore[int](store), WithDistinct[int]())
+	s.SetState(1)
+	s.SetState(1)
+	s.Update(func(cur int) int { return cur + 1 })
+
+	if got, ok, _ := store.Load(); !ok || got != 2 {
+		t.Fatalf("store holds %d (%v), want 2", got, ok)
+	}
+
+	restored := NewSubject[int](WithStore[int](store))
+	if got := restored.State(); got != 2 {
+		t.Fatalf("State() of restored subject = %d, want 2", got)
+	}
+}
+
+func TestWithStoreBatch(t *testing.T) {
+	t.Parallel()
+
+	saves := &countingStore{}
+	s := NewSubject[int](WithStore[int](saves))
+	_ = s.Batch(func(tx *Tx[int]) error {
+		for i := 1; i <= 10; i++ {
+			tx.Set(i)
+		}
+		return nil
+	},
+	)
+	_ = s.Batch(func(tx *Tx[int]) error {
+		tx.Set(100)
+		return errors.New("rollback")
+	},
+	)
+
+	if want := []int{10}; !reflect.DeepEqual(saves.states, want) {
+		t.Fatalf("store saved %v, want %v", saves.states, want)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+
+	for name, codec := range map[string]Codec{"JSON": JSON, "Gob": Gob} {
+		codec := codec
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "state")
+			store := NewFileStore[storeState](path, codec)
+
+			if _, ok, err := store.Load(); ok || err != nil {
+				t.Fatalf("Load() of missing file = %v, %v, want false, nil", ok, err)
+			}
+
+			want := storeState{Name: "doc", Items: []int{1, 2, 3}}
+			s := NewSubject[storeState](WithStore[storeState](store))
+			s.SetState(storeState{Name: "draft"})
+			s.SetState(want)
+
+			restored := NewSubject[storeState](WithStore[storeState](NewFileStore[storeState](path, codec)))
+			if got := restored.State(); !reflect.DeepEqual(got, want) {
+				t.Fatalf("State() of restored subject = %+v, want %+v", got, want)
+			}
+
+			entries, err := os.ReadDir(filepath.Dir(path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("store left %d files, want 1", len(entries))
+			}
+		},
+		)
+	}
+}
+
+// countingStore is a helper Store that records every saved state.
+type countingStore struct {
+	MemoryStore[int]
+	states []int
+}
+
+func (c *countingStore) Save(state int) error {
+	c.states = append(c.states, state)
+	return c.MemoryStore.Save(state)
+}
+
+// negative tests
+
+func TestFileStoreCorrupted(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &errorRecorder{}
+	s := NewSubject[int](WithStore[int](NewFileStore[int](path, JSON)), WithErrorHandler[int](r.handle))
+
+	var storeErr *StoreError
+	if r.len() != 1 || !errors.As(r.errs[0], &storeErr) || storeErr.Op != "load" {
+		t.Fatalf("reported errors %v, want single load *StoreError", r.errs)
+	}
+	if got := s.State(); got != 0 {
+		t.Fatalf("State() = %d, want zero value", got)
+	}
+}
+
+func TestFileStoreSaveFailure(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "missing", "state.json")
+	r := &errorRecorder{}
+	s := NewSubject[int](WithStore[int](NewFileStore[int](path, JSON)), WithErrorHandler[int](r.handle))
+	o := &testObserver{}
+	s.Attach(o)
+	s.SetState(1)
+
+	var storeErr *StoreError
+	if r.len() != 1 || !errors.As(r.errs[0], &storeErr) || storeErr.Op != "save" {
+		t.Fatalf("reported errors %v, want single save *StoreError", r.errs)
+	}
+	if got, ok := o.lastState(t); !ok || got != 1 {
+		t.Fatalf("observer was not notified after failed save")
+	}
+}
+
+func TestWithStoreNilShouldPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("WithStore(nil) did not panic")
+		}
+	}()
+	WithStore[int](nil)
+}
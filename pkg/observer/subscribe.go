@@ -0,0 +1,97 @@
+package observer
+
+import (
+	"context"
+	"sync"
+)
+
+// chanObserver is an Observer that sends every state change to a channel.
+type chanObserver[T any] struct {
+	mu     sync.Mutex // protects ch from being closed during send
+	ch     chan Event[T]
+	ctx    context.Context
+	done   chan struct{}
+	once   sync.Once
+	closed bool
+}
+
+// Update sends state change to the channel.
+func (o *chanObserver[T]) Update(state, prevState T) {
+	o.OnEvent(Event[T]{State: state, PrevState: prevState})
+}
+
+// OnEvent sends e to the channel, waiting for the receiver
+// until subscription context is done or subscription is closed.
+func (o *chanObserver[T]) OnEvent(e Event[T]) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return
+	}
+
+	select {
+	case o.ch <- e:
+	case <-o.ctx.Done():
+	case <-o.done:
+	}
+}
+
+// close closes the channel, unblocking pending Update call first.
+func (o *chanObserver[T]) close() {
+	o.once.Do(func() {
+		close(o.done)
+
+		o.mu.Lock()
+		o.closed = true
+		close(o.ch)
+		o.mu.Unlock()
+	},
+	)
+}
+
+// Subscribe returns a channel that receives every state change of current Subject.
+// Channel has buffer of given size, when it is full SetState waits for the receiver
+// (or for the queue of asynchronous Subject to accept the change).
+// Channel is closed once ctx is done or Subject is closed.
+// Subject must be initialized before Subscribe calls.
+func (s *Subject[T]) Subscribe(ctx context.Context, bufSize int) <-chan Event[T] {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	return s.SubscribeWith(ctx, bufSize)
+}
+
+// SubscribeWith works like Subscribe, but configures subscription with given options.
+// Subject must be initialized before SubscribeWith calls.
+func (s *Subject[T]) SubscribeWith(ctx context.Context, bufSize int, opts ...AttachOption[T]) <-chan Event[T] {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	o := &chanObserver[T]{
+		ch:   make(chan Event[T], bufSize),
+		ctx:  ctx,
+		done: make(chan struct{}),
+	}
+
+	sub, ok := s.attach([]Observer[T]{o}, opts...)
+	if !ok {
+		o.close()
+		return o.ch
+	}
+	// replayed changes must not block the caller that has not got the channel yet
+	go sub.replay()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.Unsubscribe()
+			o.close()
+		case <-o.done:
+		}
+	}()
+
+	return o.ch
+}
@@ -0,0 +1,124 @@
+package observer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// receive reads the next event from ch or fails after a timeout.
+func receive(t *testing.T, ch <-chan Event[int]) (Event[int], bool) {
+	t.Helper()
+
+	select {
+	case e, ok := <-ch:
+		return e, ok
+	case <-time.After(time.Second):
+		t.Fatalf("no event received within 1s")
+	}
+	return Event[int]{}, false
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	ch := s.Subscribe(context.Background(), 2)
+
+	s.SetState(1)
+	s.SetState(2)
+
+	for _, want := range []Event[int]{{State: 1, PrevState: 0, Version: 1}, {State: 2, PrevState: 1, Version: 2}} {
+		got, ok := receive(t, ch)
+		if got.Time.IsZero() {
+			t.Fatalf("received event without time: %+v", got)
+		}
+		got.Time = time.Time{}
+		if !ok || got != want {
+			t.Fatalf("received %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestSubscribeUnbuffered(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	ch := s.Subscribe(context.Background(), 0)
+
+	go func() {
+		for i := 1; i <= 3; i++ {
+			s.SetState(i)
+		}
+	}()
+
+	for i := 1; i <= 3; i++ {
+		if got, ok := receive(t, ch); !ok || got.State != i {
+			t.Fatalf("received %+v, want state %d", got, i)
+		}
+	}
+}
+
+func TestSubscribeContextCancel(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := s.Subscribe(ctx, 0)
+
+	// nobody receives, SetState must be released by cancellation
+	released := make(chan struct{})
+	go func() {
+		s.SetState(1)
+		close(released)
+	}()
+
+	cancel()
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatalf("SetState was not released by context cancellation")
+	}
+
+	eventually(t, time.Second, func() bool {
+		select {
+		case _, ok := <-ch:
+			return !ok
+		default:
+			return false
+		}
+	},
+	)
+	eventually(t, time.Second, func() bool { return len(attached(s)) == 0 })
+}
+
+func TestSubscribeClose(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithAsync[int](4, Block))
+	ch := s.Subscribe(context.Background(), 4)
+
+	s.SetState(1)
+	s.Close()
+
+	if got, ok := receive(t, ch); !ok || got.State != 1 {
+		t.Fatalf("received %+v, want pending state 1", got)
+	}
+	if _, ok := receive(t, ch); ok {
+		t.Fatalf("channel is not closed after Close")
+	}
+}
+
+// negative tests
+
+func TestSubscribeClosedSubject(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	s.Close()
+
+	ch := s.Subscribe(context.Background(), 1)
+	if _, ok := receive(t, ch); ok {
+		t.Fatalf("channel of closed subject is not closed")
+	}
+}
@@ -0,0 +1,110 @@
+package observer
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTxDone is returned by Commit and Rollback of finished Tx.
+var ErrTxDone = errors.New("observer: transaction has already been committed or rolled back")
+
+// Tx is a batch of state changes of Subject that notifies Observer instances once,
+// about the change from state before Tx began to state at Commit.
+// Other state changes of Subject wait until Tx is finished,
+// so Tx must be finished with Commit or Rollback.
+// Tx must not be used from several goroutines at once.
+type Tx[T any] struct {
+	subject *Subject[T]
+	state   T
+	dirty   bool
+	done    bool
+}
+
+// Begin starts new Tx on current Subject.
+// State changes made outside of Tx (in the same goroutine too) wait until it finishes.
+// Subject must be initialized before Begin calls.
+func (s *Subject[T]) Begin() *Tx[T] {
+	if s == nil {
+		panic("subject is not initialized")
+	}
+
+	s.notifyMu.Lock()
+	return &Tx[T]{
+		subject: s,
+		state:   s.State(),
+	}
+}
+
+// Batch runs fn inside new Tx. Tx is committed if fn returns nil,
+// and rolled back if fn returns error or panics.
+// Batch returns error of fn or of Commit.
+// If fn finishes Tx itself with Commit or Rollback, Batch only returns error of fn.
+// Subject must be initialized before Batch calls.
+func (s *Subject[T]) Batch(fn func(tx *Tx[T]) error) error {
+	tx := s.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if tx.done {
+		return nil
+	}
+	return tx.Commit()
+}
+
+// State returns state of current Tx, including changes that are not committed yet.
+func (tx *Tx[T]) State() T {
+	return tx.state
+}
+
+// Set changes state of current Tx, Observer instances are not notified until Commit.
+func (tx *Tx[T]) Set(state T) {
+	if tx.done {
+		panic("transaction is done")
+	}
+
+	tx.state = state
+	tx.dirty = true
+}
+
+// Update changes state of current Tx to the result of fn.
+func (tx *Tx[T]) Update(fn func(cur T) T) {
+	tx.Set(fn(tx.state))
+}
+
+// Commit applies state of current Tx to Subject and notifies Observer instances once.
+// Nothing is notified if state was never set. Like SetStateErr, Commit returns
+// failures of Observer instances as *NotifyError.
+func (tx *Tx[T]) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	defer tx.subject.notifyMu.Unlock()
+
+	if !tx.dirty {
+		return nil
+	}
+	if err := tx.subject.set(context.Background(), tx.state, changeSet); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Rollback discards state of current Tx, Subject keeps the state it had before Tx began.
+func (tx *Tx[T]) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.subject.notifyMu.Unlock()
+
+	return nil
+}
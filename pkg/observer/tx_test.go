@@ -0,0 +1,203 @@
+package observer
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	s.SetState(1)
+	o := &transitionObserver{}
+	s.Attach(o)
+
+	err := s.Batch(func(tx *Tx[int]) error {
+		for i := 0; i < 1000; i++ {
+			tx.Update(func(cur int) int { return cur + 1 })
+		}
+		if got := s.State(); got != 1 {
+			t.Errorf("State() during batch = %d, want 1", got)
+		}
+		return nil
+	},
+	)
+	if err != nil {
+		t.Fatalf("Batch() = %v, want nil", err)
+	}
+
+	if want := [][2]int{{1001, 1}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("observer received %v, want %v", o.transitions, want)
+	}
+}
+
+func TestBatchCommittedByFn(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o := &transitionObserver{}
+	s.Attach(o)
+
+	err := s.Batch(func(tx *Tx[int]) error {
+		tx.Set(1)
+		return tx.Commit()
+	},
+	)
+	if err != nil {
+		t.Fatalf("Batch() = %v, want nil", err)
+	}
+
+	if want := [][2]int{{1, 0}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("observer received %v, want %v", o.transitions, want)
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o := &testObserver{}
+	s.Attach(o)
+
+	errImport := errors.New("bad row")
+	err := s.Batch(func(tx *Tx[int]) error {
+		tx.Set(10)
+		return errImport
+	},
+	)
+	if err != errImport {
+		t.Fatalf("Batch() = %v, want %v", err, errImport)
+	}
+
+	if got := s.State(); got != 0 {
+		t.Fatalf("State() after failed batch = %d, want 0", got)
+	}
+	if len(o.states) != 0 {
+		t.Fatalf("observer notified about failed batch: %v", o.states)
+	}
+
+	// subject must be usable after rollback
+	s.SetState(1)
+	if len(o.states) != 1 {
+		t.Fatalf("observer received %d updates after rollback, want 1", len(o.states))
+	}
+}
+
+func TestBatchPanic(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o := &testObserver{}
+	s.Attach(o)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("Batch() panicked with %v, want boom", r)
+			}
+		}()
+		_ = s.Batch(func(tx *Tx[int]) error {
+			tx.Set(10)
+			panic("boom")
+		},
+		)
+	}()
+
+	if got := s.State(); got != 0 {
+		t.Fatalf("State() after panicked batch = %d, want 0", got)
+	}
+	s.SetState(1)
+	if len(o.states) != 1 {
+		t.Fatalf("observer received %d updates, want 1", len(o.states))
+	}
+}
+
+func TestBeginCommit(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int](WithDistinct[int]())
+	o := &transitionObserver{}
+	s.Attach(o)
+
+	tx := s.Begin()
+	tx.Set(5)
+	tx.Set(7)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+
+	// state returns to the pre-batch value, distinct subject skips it
+	tx = s.Begin()
+	tx.Set(1)
+	tx.Set(7)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+
+	// nothing set, nothing notified
+	tx = s.Begin()
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+
+	if want := [][2]int{{7, 0}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("observer received %v, want %v", o.transitions, want)
+	}
+}
+
+func TestTxBlocksOtherChanges(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	o := &transitionObserver{}
+	s.Attach(o)
+
+	tx := s.Begin()
+	tx.Set(1)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.SetState(2)
+	}()
+
+	tx.Set(3)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+	wg.Wait()
+
+	if want := [][2]int{{3, 0}, {2, 3}}; !reflect.DeepEqual(o.transitions, want) {
+		t.Fatalf("observer received %v, want %v", o.transitions, want)
+	}
+}
+
+// negative tests
+
+func TestTxDone(t *testing.T) {
+	t.Parallel()
+
+	s := NewSubject[int]()
+	tx := s.Begin()
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback() = %v, want nil", err)
+	}
+
+	if err := tx.Commit(); err != ErrTxDone {
+		t.Fatalf("Commit() after Rollback = %v, want %v", err, ErrTxDone)
+	}
+	if err := tx.Rollback(); err != ErrTxDone {
+		t.Fatalf("second Rollback() = %v, want %v", err, ErrTxDone)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("Set on finished transaction did not panic")
+		}
+	}()
+	tx.Set(1)
+}